@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// AgentProfile is a named combination of model provider, model, system prompt, tool set
+// and output formatter. Clients pick a profile with the model field of the request.
+type AgentProfile struct {
+	ID           string
+	Provider     LLMProvider
+	Model        string
+	SystemPrompt string
+	Tools        CompletionTools
+	Formatter    responseFormatter
+	Temperature  *float32
+	// Template of the product cards rendered for mostrarProductos
+	Cards *template.Template
+}
+
+// profilesFile is the format of the profiles config file (see profiles.example.json)
+type profilesFile struct {
+	Default  string          `json:"default"`
+	Profiles []profileConfig `json:"profiles"`
+}
+
+type profileConfig struct {
+	ID string `json:"id"`
+	// Provider name ("gemini" or "openai") and model, empty uses Gemini with GEMINI_MODEL
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// System prompt inline or in a file relative to the config file, both empty use the built-in prompt
+	SystemPrompt     string `json:"system_prompt"`
+	SystemPromptFile string `json:"system_prompt_file"`
+	// Names of the tools the profile may use, empty allows every tool
+	Tools       []string `json:"tools"`
+	Formatter   string   `json:"formatter"`
+	Temperature *float32 `json:"temperature"`
+	// Product card template file relative to the config file, empty uses the built-in WhatsApp card
+	CardTemplateFile string `json:"card_template_file"`
+}
+
+// loadProfiles reads the profile registry from the config file.
+// When the file does not exist only the built-in default profile is served.
+func loadProfiles(cfg Config, providers map[string]LLMProvider, tools CompletionTools) (*profileRegistry, error) {
+	data, err := os.ReadFile(cfg.ProfilesFile)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("profiles file %s not found, using the default profile...\n", cfg.ProfilesFile)
+		return newProfileRegistry(cfg.DefaultProfile, defaultProfiles(cfg, providers, tools)...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles file: %w", err)
+	}
+
+	var file profilesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles file: %w", err)
+	}
+
+	var profiles []*AgentProfile
+	for _, pc := range file.Profiles {
+		profile, err := pc.build(cfg, providers, tools, filepath.Dir(cfg.ProfilesFile))
+		if err != nil {
+			return nil, fmt.Errorf("invalid profile %q: %w", pc.ID, err)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	defaultID := file.Default
+	if defaultID == "" {
+		defaultID = cfg.DefaultProfile
+	}
+	return newProfileRegistry(defaultID, profiles...)
+}
+
+func (pc profileConfig) build(cfg Config, providers map[string]LLMProvider, tools CompletionTools, baseDir string) (*AgentProfile, error) {
+	if pc.ID == "" {
+		return nil, fmt.Errorf("missing id")
+	}
+
+	profile := &AgentProfile{
+		ID:           pc.ID,
+		Model:        pc.Model,
+		SystemPrompt: pc.SystemPrompt,
+		Tools:        tools.forRole(roleSales),
+		Temperature:  pc.Temperature,
+	}
+
+	providerName := pc.Provider
+	if providerName == "" {
+		providerName = "gemini"
+	}
+	provider, err := getProvider(providers, providerName)
+	if err != nil {
+		return nil, err
+	}
+	profile.Provider = provider
+
+	if profile.Model == "" {
+		if providerName != "gemini" {
+			return nil, fmt.Errorf("missing model for provider %q", providerName)
+		}
+		profile.Model = cfg.GeminiModel
+	}
+
+	switch {
+	case pc.SystemPrompt != "" && pc.SystemPromptFile != "":
+		return nil, fmt.Errorf("set system_prompt or system_prompt_file, not both")
+	case pc.SystemPromptFile != "":
+		path := pc.SystemPromptFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		prompt, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read system prompt: %w", err)
+		}
+		profile.SystemPrompt = string(prompt)
+	case pc.SystemPrompt == "":
+		profile.SystemPrompt = getSystemPrompt()
+	}
+
+	// Profiles that do not list their tools get the sales tools, manager only tools must be listed
+	if len(pc.Tools) > 0 {
+		subset, err := tools.subset(pc.Tools)
+		if err != nil {
+			return nil, err
+		}
+		profile.Tools = subset
+	}
+
+	formatterName := pc.Formatter
+	if formatterName == "" {
+		formatterName = "whatsapp"
+	}
+	formatter, err := getResponseFormatter(formatterName)
+	if err != nil {
+		return nil, err
+	}
+	profile.Formatter = formatter
+
+	if pc.Temperature != nil && (*pc.Temperature < 0 || *pc.Temperature > 2) {
+		return nil, fmt.Errorf("temperature must be between 0 and 2")
+	}
+
+	profile.Cards = defaultCards
+	if pc.CardTemplateFile != "" {
+		path := pc.CardTemplateFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		profile.Cards, err = loadCardTemplate(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return profile, nil
+}
+
+// profileRegistry holds the profiles served by the agent, in the order they are listed
+type profileRegistry struct {
+	profiles  []*AgentProfile
+	defaultID string
+	created   int64
+}
+
+func newProfileRegistry(defaultID string, profiles ...*AgentProfile) (*profileRegistry, error) {
+	registry := &profileRegistry{
+		defaultID: defaultID,
+		created:   time.Now().Unix(),
+	}
+	for _, profile := range profiles {
+		if _, ok := registry.get(profile.ID); ok {
+			return nil, fmt.Errorf("duplicated profile %q", profile.ID)
+		}
+		registry.profiles = append(registry.profiles, profile)
+	}
+	if _, ok := registry.get(defaultID); !ok {
+		return nil, fmt.Errorf("default profile %q is not registered", defaultID)
+	}
+	return registry, nil
+}
+
+// defaultProfiles returns the built-in profile, using Gemini, every sales tool and the default system prompt
+func defaultProfiles(cfg Config, providers map[string]LLMProvider, tools CompletionTools) []*AgentProfile {
+	return []*AgentProfile{
+		{
+			ID:           cfg.DefaultProfile,
+			Provider:     providers["gemini"],
+			Model:        cfg.GeminiModel,
+			SystemPrompt: getSystemPrompt(),
+			Tools:        tools.forRole(roleSales),
+			Formatter:    responseFormatters["whatsapp"],
+			Cards:        defaultCards,
+		},
+	}
+}
+
+func (r *profileRegistry) get(id string) (*AgentProfile, bool) {
+	for _, profile := range r.profiles {
+		if profile.ID == id {
+			return profile, true
+		}
+	}
+	return nil, false
+}
+
+// resolve returns the profile for the model requested by the client, an empty model uses the default profile
+func (r *profileRegistry) resolve(model string) (*AgentProfile, bool) {
+	if model == "" {
+		model = r.defaultID
+	}
+	return r.get(model)
+}
+
+// Model list in the format of the OpenAI GET /v1/models endpoint
+
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type OpenAIModelList struct {
+	Object string        `json:"object"`
+	Data   []OpenAIModel `json:"data"`
+}
+
+// modelsHandler lists the agent profiles so OpenAI clients can discover them
+func (app *App) modelsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.Printf("method not allowed: %v...\n", r.Method)
+		writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	list := OpenAIModelList{Object: "list", Data: []OpenAIModel{}}
+	for _, profile := range app.profiles.profiles {
+		list.Data = append(list.Data, OpenAIModel{
+			ID:      profile.ID,
+			Object:  "model",
+			Created: app.profiles.created,
+			OwnedBy: "copocar",
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	codeRegexp  = regexp.MustCompile(`C[óo]digo:?\*?:?\s*([0-9A-Za-z-]+)`)
+	priceRegexp = regexp.MustCompile(`\$\s*([0-9][0-9,]*(?:\.[0-9]+)?)`)
+	stockRegexp = regexp.MustCompile(`(?i)existencia[^:\n]*:\*?\s*([0-9][0-9,]*(?:\.[0-9]+)?)`)
+)
+
+// answerFacts are the product codes, prices and stock figures quoted in an answer
+type answerFacts struct {
+	Codes  []string
+	Prices []float64
+	Stock  []float64
+}
+
+// extractAnswerFacts finds the figures of the WhatsApp product format in the text
+func extractAnswerFacts(text string) answerFacts {
+	var facts answerFacts
+	for _, match := range codeRegexp.FindAllStringSubmatch(text, -1) {
+		facts.Codes = append(facts.Codes, match[1])
+	}
+	for _, match := range priceRegexp.FindAllStringSubmatch(text, -1) {
+		if value, ok := parseFigure(match[1]); ok {
+			facts.Prices = append(facts.Prices, value)
+		}
+	}
+	for _, match := range stockRegexp.FindAllStringSubmatch(text, -1) {
+		if value, ok := parseFigure(match[1]); ok {
+			facts.Stock = append(facts.Stock, value)
+		}
+	}
+	facts.Codes = uniqueStrings(facts.Codes)
+	return facts
+}
+
+func parseFigure(text string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", ""), 64)
+	return value, err == nil
+}
+
+// toolFacts are the prices and stock figures returned by the tools of a request
+type toolFacts struct {
+	Prices map[string]bool
+	Stock  map[string]bool
+}
+
+// Amounts computed by the tools that the answers quote as prices, e.g. the totals of cotizar
+var amountFields = map[string]bool{"Importe": true, "Subtotal": true, "Iva": true, "Total": true}
+
+// collectToolFacts walks the tool results and keeps every Precio*, amount and Existencia* value,
+// rounded to two decimals as the answers show them
+func collectToolFacts(exchanges []toolExchange) toolFacts {
+	facts := toolFacts{Prices: map[string]bool{}, Stock: map[string]bool{}}
+	for _, response := range decodedToolResponses(exchanges) {
+		facts.walk("", response)
+	}
+	return facts
+}
+
+// decodedToolResponses returns the tool results as decoded JSON. The round trip makes typed rows
+// and decoded maps look the same. Results that can not be encoded are skipped.
+func decodedToolResponses(exchanges []toolExchange) []any {
+	var responses []any
+	for _, exchange := range exchanges {
+		data, err := json.Marshal(exchange.Response)
+		if err != nil {
+			continue
+		}
+		var response any
+		if err := json.Unmarshal(data, &response); err != nil {
+			continue
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func (f toolFacts) walk(key string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			f.walk(k, child)
+		}
+	case []any:
+		for _, child := range v {
+			f.walk(key, child)
+		}
+	case float64:
+		switch {
+		case strings.HasPrefix(key, "Precio") || amountFields[key]:
+			f.Prices[figureKey(v)] = true
+		case strings.HasPrefix(key, "Existencia"):
+			f.Stock[figureKey(v)] = true
+		}
+	}
+}
+
+func figureKey(value float64) string {
+	return fmt.Sprintf("%.2f", math.Round(value*100)/100)
+}
+
+// unverifiedFigures returns the prices and stock figures of the answer that no tool returned
+func unverifiedFigures(answer answerFacts, tools toolFacts) (prices, stock []float64) {
+	for _, price := range answer.Prices {
+		if !tools.Prices[figureKey(price)] {
+			prices = append(prices, price)
+		}
+	}
+	for _, value := range answer.Stock {
+		if !tools.Stock[figureKey(value)] {
+			stock = append(stock, value)
+		}
+	}
+	return prices, stock
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnverifiedFigures(t *testing.T) {
+	answer := "*Código:* 101\n*PECHUGA DE POLLO*\n*Existencia:* 120\n*Precio Detalle:* $95.50\n" +
+		"*Código:* 205\n*SALCHICHA DE PAVO*\n*Existencia:* 40\n*Precio Mayoreo:* $1,250.00"
+
+	facts := extractAnswerFacts(answer)
+	if want := []string{"101", "205"}; !reflect.DeepEqual(facts.Codes, want) {
+		t.Fatalf("codes = %v, want %v", facts.Codes, want)
+	}
+
+	tools := collectToolFacts([]toolExchange{{
+		Name: "obtenerInformacionPorCodigo",
+		Response: map[string]any{"result": []map[string]any{
+			{"Vcodigo": "101", "Existencia": 120, "PrecioDetalle": 95.5},
+			{"Vcodigo": "205", "Existencia": 35, "PrecioMayoreo": 1200},
+		}},
+	}})
+
+	prices, stock := unverifiedFigures(facts, tools)
+	if want := []float64{1250}; !reflect.DeepEqual(prices, want) {
+		t.Errorf("unverified prices = %v, want %v", prices, want)
+	}
+	if want := []float64{40}; !reflect.DeepEqual(stock, want) {
+		t.Errorf("unverified stock = %v, want %v", stock, want)
+	}
+}
+
+// The amounts of a quote come from cotizar and are not flagged
+func TestUnverifiedFiguresQuoteAmounts(t *testing.T) {
+	answer := "PECHUGA DE POLLO 10 kg a $95.50 = $955.00\nSubtotal: $955.00\nIVA: $0.00\nTotal: $955.00"
+	tools := collectToolFacts([]toolExchange{{
+		Name: "cotizar",
+		Response: map[string]any{"result": quote{
+			Lineas:   []quoteLine{{Codigo: "101", Kg: 10, PrecioKg: 95.5, Importe: 955}},
+			Subtotal: 955,
+			Total:    955,
+		}},
+	}})
+	if prices, _ := unverifiedFigures(extractAnswerFacts(answer), tools); len(prices) > 0 {
+		t.Errorf("unverified prices = %v, want none", prices)
+	}
+}
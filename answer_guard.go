@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Labels of the WhatsApp product format, with the row field each figure of the line maps to.
+// The first figure after "$" is the price, the following ones are the scales in parentheses.
+// Labels must end with a colon, so tiers named in running text (e.g. a quote line) are not taken as prices.
+var answerLineFields = []struct {
+	label  *regexp.Regexp
+	fields []string
+}{
+	{regexp.MustCompile(`(?i)medio\s+mayoreo\*?:`), []string{"PrecioMedioMayoreo", "EscalaDetalle", "EscalaMedioMayoreo"}},
+	{regexp.MustCompile(`(?i)mayoreo\*?:`), []string{"PrecioMayoreo", "EscalaMedioMayoreo"}},
+	{regexp.MustCompile(`(?i)detalle\*?:`), []string{"PrecioDetalle", "EscalaDetalle"}},
+	{regexp.MustCompile(`(?i)existencia(?:\s+kg)?\*?:`), []string{"ExistenciaKg"}},
+}
+
+var numberRegexp = regexp.MustCompile(`[0-9]+(?:,[0-9]{3})*(?:\.[0-9]+)?`)
+
+// Names of the checked fields as shown to the customer
+var answerFieldLabels = map[string]string{
+	"PrecioDetalle":      "Precio detalle",
+	"EscalaDetalle":      "Escala detalle",
+	"PrecioMedioMayoreo": "Precio medio mayoreo",
+	"EscalaMedioMayoreo": "Escala medio mayoreo",
+	"PrecioMayoreo":      "Precio mayoreo",
+	"ExistenciaKg":       "Existencia",
+}
+
+// answerIssue is a figure of the answer that does not match the product data returned by the tools.
+// An empty Field means the answer quotes figures for a code that no tool returned.
+type answerIssue struct {
+	Code     string  `json:"code"`
+	Field    string  `json:"field,omitempty"`
+	Quoted   float64 `json:"quoted,omitempty"`
+	Expected float64 `json:"expected,omitempty"`
+}
+
+// answerCheck is the result of checking an answer against the tool results
+type answerCheck struct {
+	// Answer with every mismatched figure replaced by the value of the tools
+	Text string
+	// Figures that were corrected in Text
+	Corrected []answerIssue
+	// Codes quoted with prices or stock that no tool returned, they can not be corrected
+	Unknown []string
+}
+
+// answerFigure is a number of a product block, located by its byte offsets in the answer
+type answerFigure struct {
+	code       string
+	field      string
+	value      float64
+	start, end int
+}
+
+// checkAnswer splits the answer into product blocks, one per "Código" line, and compares every
+// price, scale and stock figure of a block with the row of that code returned by the tools
+func checkAnswer(text string, exchanges []toolExchange) answerCheck {
+	check := answerCheck{Text: text}
+	rows := productRows(exchanges)
+
+	var replacements []answerFigure
+	unknown := map[string]bool{}
+	for _, figure := range answerFigures(text) {
+		row, ok := rows[figure.code]
+		if !ok {
+			unknown[figure.code] = true
+			continue
+		}
+		expected, ok := row[figure.field]
+		if !ok || figureKey(expected) == figureKey(figure.value) {
+			continue
+		}
+		check.Corrected = append(check.Corrected, answerIssue{
+			Code:     figure.code,
+			Field:    figure.field,
+			Quoted:   figure.value,
+			Expected: expected,
+		})
+		figure.value = expected
+		replacements = append(replacements, figure)
+	}
+
+	// Replace from the end so the offsets of the earlier figures stay valid
+	for i := len(replacements) - 1; i >= 0; i-- {
+		figure := replacements[i]
+		quoted := check.Text[figure.start:figure.end]
+		check.Text = check.Text[:figure.start] + formatFigure(figure.value, quoted) + check.Text[figure.end:]
+	}
+
+	for code := range unknown {
+		check.Unknown = append(check.Unknown, code)
+	}
+	sort.Strings(check.Unknown)
+	return check
+}
+
+// issues returns the corrected figures and the unknown codes as a single list for the recordings
+func (c answerCheck) issues() []answerIssue {
+	issues := append([]answerIssue{}, c.Corrected...)
+	for _, code := range c.Unknown {
+		issues = append(issues, answerIssue{Code: code})
+	}
+	return issues
+}
+
+// answerFigures finds the figures of every product block of the answer
+func answerFigures(text string) []answerFigure {
+	var figures []answerFigure
+	blocks := codeRegexp.FindAllStringSubmatchIndex(text, -1)
+	for i, block := range blocks {
+		code := text[block[2]:block[3]]
+		end := len(text)
+		if i+1 < len(blocks) {
+			end = blocks[i+1][0]
+		}
+
+		offset := block[1]
+		for _, line := range strings.SplitAfter(text[offset:end], "\n") {
+			figures = append(figures, lineFigures(code, line, offset)...)
+			offset += len(line)
+		}
+	}
+	return figures
+}
+
+// lineFigures maps the numbers of a line of a product block to the fields of its label
+func lineFigures(code, line string, offset int) []answerFigure {
+	for _, lineField := range answerLineFields {
+		label := lineField.label.FindStringIndex(line)
+		if label == nil {
+			continue
+		}
+
+		rest := line[label[1]:]
+		start := offset + label[1]
+		// Prices start at the "$" sign, stock right after the label
+		if dollar := strings.Index(rest, "$"); dollar >= 0 {
+			rest = rest[dollar:]
+			start += dollar
+		} else if lineField.fields[0] != "ExistenciaKg" {
+			return nil
+		}
+
+		var figures []answerFigure
+		numbers := numberRegexp.FindAllStringIndex(rest, len(lineField.fields))
+		for i, number := range numbers {
+			value, ok := parseFigure(rest[number[0]:number[1]])
+			if !ok {
+				continue
+			}
+			figures = append(figures, answerFigure{
+				code:  code,
+				field: lineField.fields[i],
+				value: value,
+				start: start + number[0],
+				end:   start + number[1],
+			})
+		}
+		return figures
+	}
+	return nil
+}
+
+// productRows collects the checked fields of every product returned by the tools, by code.
+// Rows that only list codes and descriptions have none of the fields and are left out. The
+// fields of a code are merged over every row that carries it, and a field the tools returned
+// with two different values is dropped, since the guard can not tell which one is right.
+// ExistenciaKg is the stock that can be promised in every tool, the warehouse stock of the
+// alerts and expiry tools comes as ExistenciaFisicaKg and is not checked.
+func productRows(exchanges []toolExchange) map[string]map[string]float64 {
+	rows := map[string]map[string]float64{}
+	conflicts := map[[2]string]bool{}
+	for _, response := range decodedToolResponses(exchanges) {
+		collectProductRows(response, rows, conflicts)
+	}
+	for key := range conflicts {
+		delete(rows[key[0]], key[1])
+	}
+	return rows
+}
+
+// collectProductRows merges the fields of every row with a Codigo into rows, including the rows
+// nested in other rows (e.g. InfoActual of sugerirPedido), and records the code and field
+// pairs seen with different values in conflicts
+func collectProductRows(value any, rows map[string]map[string]float64, conflicts map[[2]string]bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		if code, ok := v["Codigo"].(string); ok {
+			for field := range answerFieldLabels {
+				var figure float64
+				switch raw := v[field].(type) {
+				case float64:
+					figure = raw
+				case string:
+					// The scales are stored as text
+					if figure, ok = parseFigure(raw); !ok {
+						continue
+					}
+				default:
+					continue
+				}
+				if rows[code] == nil {
+					rows[code] = map[string]float64{}
+				}
+				if seen, ok := rows[code][field]; !ok {
+					rows[code][field] = figure
+				} else if figureKey(seen) != figureKey(figure) {
+					conflicts[[2]string{code, field}] = true
+				}
+			}
+		}
+		for _, child := range v {
+			collectProductRows(child, rows, conflicts)
+		}
+	case []any:
+		for _, child := range v {
+			collectProductRows(child, rows, conflicts)
+		}
+	}
+}
+
+// formatFigure writes value with the decimals used by the quoted figure it replaces
+func formatFigure(value float64, quoted string) string {
+	if !strings.Contains(quoted, ".") && value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+// repromptMessage asks the model to answer again when it quoted products the tools did not return
+func repromptMessage(check answerCheck) string {
+	return fmt.Sprintf("Tu respuesta incluye precios o existencias de los códigos %s, que no aparecen en los resultados de las funciones. "+
+		"Consulta esos productos con las funciones disponibles y responde de nuevo usando únicamente los datos que devuelvan.",
+		strings.Join(check.Unknown, ", "))
+}
+
+// answerNotice tells the customer about the figures that could not be fixed in the text.
+// When corrected is true the corrections were already applied and only the unknown codes are listed.
+func answerNotice(check answerCheck, corrected bool) string {
+	var notice strings.Builder
+	if !corrected && len(check.Corrected) > 0 {
+		notice.WriteString("\n\n⚠️ *Corrección:* los datos correctos son:")
+		for _, issue := range check.Corrected {
+			fmt.Fprintf(&notice, "\n* Código %s, %s: %s", issue.Code, answerFieldLabels[issue.Field], formatFieldValue(issue.Field, issue.Expected))
+		}
+	}
+	if len(check.Unknown) > 0 {
+		fmt.Fprintf(&notice, "\n\n⚠️ *Aviso:* no se pudieron verificar los precios y existencias del código %s, confírmalos antes de cotizar.",
+			strings.Join(check.Unknown, ", "))
+	}
+	return notice.String()
+}
+
+func formatFieldValue(field string, value float64) string {
+	switch {
+	case strings.HasPrefix(field, "Precio"):
+		return fmt.Sprintf("$%.2f", value)
+	case strings.HasPrefix(field, "Escala"):
+		return formatFigure(value, "") + " Kg"
+	default:
+		return fmt.Sprintf("%.2f Kg", value)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"copo-ai-agent/internal/database"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const guardAnswerText = "*PECHUGA DE POLLO* 🐔\n" +
+	"* 🔢 *Código:* 101\n" +
+	"* 💲 *Precios por Kg:*\n" +
+	"  * 🏷 *Detalle:* $97.00 (hasta 30 Kg)\n" +
+	"  * 💰 *Medio mayoreo:* $92.00 (30-100 Kg)\n" +
+	"  * 💸 *Mayoreo:*  $89.00 (más de 120 Kg)\n" +
+	"* 📥 *Existencia Kg:* 150.00 Kg"
+
+func TestChatCompletionsCorrectsFigures(t *testing.T) {
+	provider := newFakeProvider(
+		scriptedTurn{ToolCalls: []ToolCall{{ID: "call-1", Name: "obtenerInformacionPorCodigo", Args: map[string]any{"productCodes": []any{"101"}}}}},
+		scriptedTurn{Text: guardAnswerText},
+	)
+	srv := newTestServer(t, provider)
+
+	_, body := postChat(t, srv, testAPIKey, userRequest("precio de la pechuga"))
+	var resp OpenAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "guard_corrected", []byte(resp.Choices[0].Message.Content))
+}
+
+func TestChatCompletionsRepromptsUnknownCodes(t *testing.T) {
+	provider := newFakeProvider(
+		scriptedTurn{Text: strings.Replace(guardAnswerText, "101", "999", 1)},
+		scriptedTurn{ToolCalls: []ToolCall{{ID: "call-1", Name: "obtenerInformacionPorCodigo", Args: map[string]any{"productCodes": []any{"101"}}}}},
+		scriptedTurn{Text: guardAnswerText},
+	)
+	srv := newTestServer(t, provider)
+
+	_, body := postChat(t, srv, testAPIKey, userRequest("precio de la pechuga"))
+	var resp OpenAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(provider.requests) != 3 {
+		t.Fatalf("got %d model calls, want 3", len(provider.requests))
+	}
+	reprompt := provider.requests[1].Messages
+	if last := reprompt[len(reprompt)-1]; last.Role != llmRoleUser || !strings.Contains(last.Text, "999") {
+		t.Errorf("expected a re-prompt naming code 999, got %+v", last)
+	}
+	content := resp.Choices[0].Message.Content
+	if !strings.Contains(content, "$95.50 (hasta 30 Kg)") || strings.Contains(content, "Aviso") {
+		t.Errorf("unexpected answer after the re-prompt:\n%s", content)
+	}
+}
+
+// The rows of a code are merged over every tool: a later row without prices does not hide the
+// detalle price, the warehouse stock of the alerts does not replace the stock that can be
+// promised, and the rows nested in sugerirPedido are checked too
+func TestCheckAnswerMergesToolRows(t *testing.T) {
+	info := database.GetProductsInfoByCodeRow{Codigo: "101", ExistenciaKg: 20, PrecioDetalle: 95.5, EscalaDetalle: "30"}
+	exchanges := []toolExchange{
+		{Name: "obtenerInformacionPorCodigo", Response: map[string]any{"result": []database.GetProductsInfoByCodeRow{info}}},
+		{Name: "cotizar", Response: map[string]any{"result": map[string]any{
+			"AvisosExistencia": []stockWarning{{Codigo: "101", ExistenciaKg: 20, KgCotizados: 30}},
+		}}},
+		{Name: "obtenerAlertasInventario", Response: map[string]any{"result": []stockAlertGroup{{
+			Linea: "POLLO", SobreMaximo: []stockAlert{{Codigo: "101", ExistenciaFisicaKg: 80}},
+		}}}},
+		{Name: "sugerirPedido", Response: map[string]any{"result": reorderSuggestion{Productos: []reorderLine{{
+			Codigo: "205", InfoActual: database.GetProductsInfoByCodeRow{Codigo: "205", PrecioDetalle: 78, EscalaDetalle: "20"},
+		}}}}},
+	}
+	answer := "* 🔢 *Código:* 101\n" +
+		"  * 🏷 *Detalle:* $50.00 (hasta 30 Kg)\n" +
+		"* 📥 *Existencia Kg:* 20.00 Kg\n" +
+		"* 🔢 *Código:* 205\n" +
+		"  * 🏷 *Detalle:* $80.00 (hasta 20 Kg)\n"
+
+	check := checkAnswer(answer, exchanges)
+	want := []answerIssue{
+		{Code: "101", Field: "PrecioDetalle", Quoted: 50, Expected: 95.5},
+		{Code: "205", Field: "PrecioDetalle", Quoted: 80, Expected: 78},
+	}
+	if !reflect.DeepEqual(check.Corrected, want) || len(check.Unknown) > 0 {
+		t.Errorf("corrected %+v, unknown %v, want %+v", check.Corrected, check.Unknown, want)
+	}
+
+	// Two tools that disagree on a field leave it unchecked
+	info.ExistenciaKg = 25
+	exchanges = append(exchanges, toolExchange{Name: "obtenerInformacionPorCodigo", Response: map[string]any{"result": []database.GetProductsInfoByCodeRow{info}}})
+	if row := productRows(exchanges)["101"]; row["PrecioDetalle"] != 95.5 {
+		t.Errorf("row 101 = %v, want the detalle price kept", row)
+	} else if stock, ok := row["ExistenciaKg"]; ok {
+		t.Errorf("row 101 has ExistenciaKg %v, want it dropped after the conflict", stock)
+	}
+}
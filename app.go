@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"copo-ai-agent/internal/utils"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"google.golang.org/genai"
+)
+
+// App owns the resources shared by every request: the database pool,
+// the model providers and the registered tools.
+type App struct {
+	config    Config
+	db        *sql.DB
+	queries   *database.Queries
+	providers map[string]LLMProvider
+	tools     CompletionTools
+	profiles  *profileRegistry
+	toolStats *toolStatsRegistry
+	usage     *usageLedger
+	keys      KeyStore
+	recorder  *exchangeRecorder
+	notifier  Notifier
+}
+
+func newApp(ctx context.Context, cfg Config) (*App, error) {
+	db, err := sql.Open("mysql", utils.GetConnString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
+	}
+
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: cfg.GeminiKey})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create gemini client: %w", err)
+	}
+
+	usage, err := newUsageLedger(cfg.UsageLedgerPath)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	queries := database.New(queryTimeoutDB{db: db, timeout: cfg.DBQueryTimeout})
+	keys, err := newKeyStore(cfg, queries)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	providers := newProviders(cfg, &geminiProvider{client: client})
+	policy, err := loadPricingPolicy(cfg.PricingPolicyFile)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	notifier, err := newNotifier(cfg)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	tools := getCompletionTools(cfg, policy)
+	profiles, err := loadProfiles(cfg, providers, tools)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &App{
+		config:    cfg,
+		db:        db,
+		queries:   queries,
+		providers: providers,
+		tools:     tools,
+		profiles:  profiles,
+		toolStats: newToolStatsRegistry(),
+		usage:     usage,
+		keys:      keys,
+		recorder:  newExchangeRecorder(cfg.RecordingsPath),
+		notifier:  notifier,
+	}, nil
+}
+
+// routes registers the HTTP handlers of the agent
+func (app *App) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handlerGeneric)
+	mux.HandleFunc("/healthz", app.healthHandler)
+	mux.HandleFunc("/v1/models", app.requireAPIKey(app.modelsHandler))
+	mux.HandleFunc("/v1/chat/completions", app.requireAPIKey(app.chatCompletionsHandler))
+	mux.HandleFunc("/v1/usage", app.requireAPIKey(app.usageHandler))
+	mux.HandleFunc("GET /v1/quotes/{id}/{format}", app.requireQuoteLink(app.quoteExportHandler))
+	mux.HandleFunc("GET /v1/approvals", app.requireAPIKey(app.approvalsHandler))
+	mux.HandleFunc("POST /v1/approvals/{id}/{decision}", app.requireAPIKey(app.approvalDecisionHandler))
+	return mux
+}
+
+func (app *App) Close() error {
+	return app.db.Close()
+}
+
+type healthResponse struct {
+	Status   string                   `json:"status"`
+	Database string                   `json:"database"`
+	Profile  string                   `json:"default_profile"`
+	DBStats  sql.DBStats              `json:"db_stats"`
+	Tools    map[string]toolCallStats `json:"tools"`
+}
+
+// healthHandler reports whether the database is reachable, the pool usage and the tool counters
+func (app *App) healthHandler(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{
+		Status:   "ok",
+		Database: "ok",
+		Profile:  app.config.DefaultProfile,
+		Tools:    app.toolStats.snapshot(),
+	}
+	status := http.StatusOK
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	if err := app.db.PingContext(ctx); err != nil {
+		log.Printf("health check failed to ping db: %v\n", err)
+		resp.Status = "degraded"
+		resp.Database = "unreachable"
+		status = http.StatusServiceUnavailable
+	}
+	resp.DBStats = app.db.Stats()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// States of an approval request, as stored in ai_quote_approvals.estado
+const (
+	approvalPending  = "pendiente"
+	approvalApproved = "aprobada"
+	approvalRejected = "rechazada"
+)
+
+// quoteApproval is an approval request as listed to managers
+type quoteApproval struct {
+	ID          int64      `json:"id"`
+	QuoteID     int64      `json:"quote_id"`
+	User        string     `json:"user"`
+	Customer    string     `json:"customer"`
+	Total       float64    `json:"total"`
+	Reasons     []string   `json:"reasons"`
+	Status      string     `json:"status"`
+	Reviewer    string     `json:"reviewer,omitempty"`
+	Comment     string     `json:"comment,omitempty"`
+	Created     time.Time  `json:"created"`
+	Resolved    *time.Time `json:"resolved,omitempty"`
+	PdfURL      string     `json:"pdf_url"`
+	WhatsAppURL string     `json:"whatsapp_url"`
+}
+
+// approvalsHandler serves GET /v1/approvals, the queue of quotes waiting for a manager.
+// ?estado=aprobada or rechazada lists the resolved ones instead.
+func (app *App) approvalsHandler(w http.ResponseWriter, r *http.Request) {
+	if identity, _ := identityFromContext(r.Context()); identity.Role != roleManager {
+		writeOpenAIError(w, http.StatusForbidden, "invalid_request_error", "forbidden", "Only managers can review quote approvals")
+		return
+	}
+
+	status := r.URL.Query().Get("estado")
+	if status == "" {
+		status = approvalPending
+	}
+	if status != approvalPending && status != approvalApproved && status != approvalRejected {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid_status",
+			"estado must be pendiente, aprobada or rechazada")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.config.DBQueryTimeout)
+	defer cancel()
+
+	rows, err := app.queries.ListQuoteApprovals(ctx, status)
+	if err != nil {
+		log.Printf("failed to list quote approvals: %v\n", err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "database_error", "Failed to list the quote approvals")
+		return
+	}
+
+	approvals := []quoteApproval{}
+	for _, row := range rows {
+		approval := quoteApproval{
+			ID:          row.ID,
+			QuoteID:     row.QuoteID,
+			User:        row.Usuario,
+			Customer:    row.Cliente,
+			Total:       row.Total,
+			Reasons:     strings.Split(row.Motivos, "\n"),
+			Status:      row.Estado,
+			Reviewer:    row.Revisor,
+			Comment:     row.Comentario,
+			Created:     row.Creada,
+			PdfURL:      quoteExportURL(app.config, row.QuoteID, "pdf"),
+			WhatsAppURL: quoteExportURL(app.config, row.QuoteID, "whatsapp"),
+		}
+		if row.Resuelta.Valid {
+			approval.Resolved = &row.Resuelta.Time
+		}
+		approvals = append(approvals, approval)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"object": "list",
+		"data":   approvals,
+	})
+}
+
+type approvalDecisionRequest struct {
+	Comment string `json:"comentario"`
+}
+
+// approvalDecisionHandler serves POST /v1/approvals/{id}/{decision}, with decision approve or
+// reject and an optional {"comentario": "..."} body that is passed on to the rep
+func (app *App) approvalDecisionHandler(w http.ResponseWriter, r *http.Request) {
+	identity, _ := identityFromContext(r.Context())
+	if identity.Role != roleManager {
+		writeOpenAIError(w, http.StatusForbidden, "invalid_request_error", "forbidden", "Only managers can review quote approvals")
+		return
+	}
+
+	var status string
+	switch r.PathValue("decision") {
+	case "approve":
+		status = approvalApproved
+	case "reject":
+		status = approvalRejected
+	default:
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "invalid_decision", "decision must be approve or reject")
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "approval_not_found", "Approval not found")
+		return
+	}
+
+	var req approvalDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid_json", "Invalid JSON in request body")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.config.DBQueryTimeout)
+	defer cancel()
+
+	if _, err := app.queries.GetQuoteApproval(ctx, id); errors.Is(err, sql.ErrNoRows) {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "approval_not_found", "Approval not found")
+		return
+	} else if err != nil {
+		log.Printf("failed to get quote approval %d: %v\n", id, err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "database_error", "Failed to get the approval")
+		return
+	}
+
+	result, err := app.queries.ResolveQuoteApproval(ctx, database.ResolveQuoteApprovalParams{
+		Estado:     status,
+		Revisor:    identity.User,
+		Comentario: truncateRunes(strings.TrimSpace(req.Comment), 512),
+		Resuelta:   sql.NullTime{Time: timeNow().Truncate(time.Second), Valid: true},
+		ID:         id,
+	})
+	var resolved int64
+	if err == nil {
+		resolved, err = result.RowsAffected()
+	}
+	if err != nil {
+		log.Printf("failed to resolve quote approval %d: %v\n", id, err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "database_error", "Failed to resolve the approval")
+		return
+	}
+	// Only pending requests are updated, a second decision on the same quote is refused
+	if resolved == 0 {
+		writeOpenAIError(w, http.StatusConflict, "invalid_request_error", "approval_already_resolved", "The approval was already resolved")
+		return
+	}
+	log.Printf("quote approval %d %s by %s...\n", id, status, identity.User)
+
+	approval, err := app.queries.GetQuoteApproval(ctx, id)
+	if err != nil {
+		log.Printf("failed to get quote approval %d: %v\n", id, err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "database_error", "Failed to get the approval")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":       approval.ID,
+		"quote_id": approval.QuoteID,
+		"status":   approval.Estado,
+		"reviewer": approval.Revisor,
+		"comment":  approval.Comentario,
+	})
+}
+
+// Closes the approval notices, so the rep sees where the answer to forward to the customer starts
+const approvalNoticeSeparator = "\n\n---\n\n"
+
+// approvalNotices returns the decisions on the quotes of the user that they have not been told
+// yet, as a paragraph of its own to go before the answer, and their ids to mark once the answer
+// is delivered. The notice stays out of the formatted answer, which the rep forwards to the
+// customer. Failures only lose the notice, the chat goes on.
+func (app *App) approvalNotices(ctx context.Context, user string) (string, []int64) {
+	ctx, cancel := context.WithTimeout(ctx, app.config.DBQueryTimeout)
+	defer cancel()
+	approvals, err := app.queries.ListUnnotifiedApprovals(ctx, user)
+	if err != nil {
+		log.Printf("failed to list approval notices of %s: %v\n", user, err)
+		return "", nil
+	}
+	if len(approvals) == 0 {
+		return "", nil
+	}
+
+	var notices []string
+	var ids []int64
+	for _, approval := range approvals {
+		ids = append(ids, approval.ID)
+		decision := "aprobó"
+		if approval.Estado == approvalRejected {
+			decision = "rechazó"
+		}
+		notice := fmt.Sprintf("🔔 Gerencia %s la cotización #%d de %s", decision, approval.QuoteID, approval.Cliente)
+		if approval.Comentario != "" {
+			notice += ": " + approval.Comentario
+		}
+		notices = append(notices, notice)
+	}
+	return strings.Join(notices, "\n") + approvalNoticeSeparator, ids
+}
+
+// markApprovalsNotified marks the decisions as told, after the answer that carries them was written
+func (app *App) markApprovalsNotified(ctx context.Context, ids []int64) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), app.config.DBQueryTimeout)
+	defer cancel()
+	for _, id := range ids {
+		if err := app.queries.MarkApprovalNotified(ctx, id); err != nil {
+			log.Printf("failed to mark approval %d as notified: %v\n", id, err)
+		}
+	}
+}
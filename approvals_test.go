@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postApproval(t *testing.T, srv *httptest.Server, apiKey, path, body string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var respBody bytes.Buffer
+	if _, err := respBody.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	return resp, respBody.Bytes()
+}
+
+// A quote under the cost waits for a manager, can not be exported until it is approved, and the
+// rep is told the decision in their next chat
+func TestQuoteApprovalWorkflow(t *testing.T) {
+	fixClock(t, testNow)
+
+	provider := newFakeProvider(
+		scriptedTurn{ToolCalls: []ToolCall{{ID: "call-1", Name: "guardarCotizacion", Args: map[string]any{
+			"cliente": "Carnicería Don Pepe",
+			"productos": []any{
+				map[string]any{"codigo": "101", "cantidad": 3.0, "unidad": "cajas"},
+				map[string]any{"codigo": "205", "cantidad": 50.0, "unidad": "piezas", "precioKg": 60.0},
+			},
+		}}}},
+		scriptedTurn{Text: "La cotización quedó pendiente de aprobación."},
+		scriptedTurn{Text: "Buenos días, ¿en qué te ayudo?"},
+		scriptedTurn{Text: "¿Algo más?"},
+	)
+	srv := newTestServer(t, provider)
+	saveTestQuote(t, srv)
+
+	results := provider.toolResults()
+	if len(results) != 1 {
+		t.Fatalf("got %d tool results, want 1", len(results))
+	}
+	saved, _ := json.Marshal(results[0].Response)
+	if !strings.Contains(string(saved), quoteStatusPending) || !strings.Contains(string(saved), "debajo del costo") {
+		t.Errorf("the saved quote is not pending approval: %s", saved)
+	}
+	if strings.Contains(string(saved), "61.75") {
+		t.Errorf("the cost reached the sales rep: %s", saved)
+	}
+
+	resp, body := getQuote(t, srv, testAPIKey, "/v1/quotes/1/pdf")
+	if resp.StatusCode != http.StatusConflict || !strings.Contains(string(body), "quote_pending_approval") {
+		t.Fatalf("got %d %s, want the export held until approval", resp.StatusCode, body)
+	}
+
+	resp, body = getQuote(t, srv, testAPIKey, "/v1/approvals")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("got %d %s, want sales reps kept out of the queue", resp.StatusCode, body)
+	}
+	resp, body = getQuote(t, srv, testManagerAPIKey, "/v1/approvals")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	var queue struct {
+		Data []quoteApproval `json:"data"`
+	}
+	if err := json.Unmarshal(body, &queue); err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.Data) != 1 || queue.Data[0].QuoteID != 1 || !strings.Contains(queue.Data[0].Reasons[0], "costo $61.75") {
+		t.Fatalf("unexpected approval queue %s", body)
+	}
+
+	path := "/v1/approvals/1/approve"
+	comment := `{"comentario": "Solo por esta vez"}`
+	if resp, body := postApproval(t, srv, testAPIKey, path, comment); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("got %d %s, want sales reps unable to approve", resp.StatusCode, body)
+	}
+	if resp, body := postApproval(t, srv, testManagerAPIKey, path, comment); resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	if resp, body := postApproval(t, srv, testManagerAPIKey, "/v1/approvals/1/reject", ""); resp.StatusCode != http.StatusConflict {
+		t.Errorf("got %d %s, want a resolved approval to stay resolved", resp.StatusCode, body)
+	}
+	if resp, body := postApproval(t, srv, testManagerAPIKey, "/v1/approvals/7/approve", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got %d %s, want an unknown approval not found", resp.StatusCode, body)
+	}
+
+	if resp, body := getQuote(t, srv, testAPIKey, "/v1/quotes/1/whatsapp"); resp.StatusCode != http.StatusOK {
+		t.Errorf("got %d %s, want the approved quote exported", resp.StatusCode, body)
+	}
+
+	// The notice goes in a chunk of its own before the header of the answer, so the WhatsApp block
+	// the rep forwards to the customer does not carry it, and it is told once
+	notice := "🔔 Gerencia aprobó la cotización #1 de Carnicería Don Pepe: Solo por esta vez"
+	req := userRequest("hola")
+	req.Stream = true
+	resp, body = postChat(t, srv, testAPIKey, req)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	chunks := streamedContents(t, body)
+	if len(chunks) == 0 || chunks[0] != notice+approvalNoticeSeparator {
+		t.Fatalf("the first chunk is not the approval notice alone: %q", chunks)
+	}
+	block := strings.Join(chunks[1:], "")
+	if want := responseFormatters["whatsapp"].format("Buenos días, ¿en qué te ayudo?"); block != want || strings.Contains(block, notice) {
+		t.Errorf("unexpected WhatsApp block\n--- got\n%s\n--- want\n%s", block, want)
+	}
+
+	_, body = postChat(t, srv, testAPIKey, userRequest("hola"))
+	if strings.Contains(string(body), notice) {
+		t.Errorf("the approval notice was told twice\n%s", body)
+	}
+}
+
+// streamedContents returns the content of every chunk of a stream that carries some
+func streamedContents(t *testing.T, body []byte) []string {
+	t.Helper()
+
+	var contents []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk OpenAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				contents = append(contents, choice.Delta.Content)
+			}
+		}
+	}
+	return contents
+}
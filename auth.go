@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Roles assigned to the API keys
+const (
+	roleSales   = "ventas"
+	roleManager = "gerente"
+)
+
+// Identity is the salesperson or branch behind an API key
+type Identity struct {
+	User   string `json:"user"`
+	Name   string `json:"name"`
+	Branch string `json:"branch"`
+	Role   string `json:"role"`
+}
+
+// KeyStore resolves an API key to the identity it belongs to
+type KeyStore interface {
+	Lookup(ctx context.Context, apiKey string) (Identity, bool, error)
+}
+
+// fileKeyStore holds the API keys loaded from a JSON file:
+// [{"key": "...", "user": "jperez", "name": "Juan Pérez", "branch": "Tula", "role": "ventas"}]
+type fileKeyStore struct {
+	keys map[string]Identity
+}
+
+func newFileKeyStore(path string) (*fileKeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api keys file: %w", err)
+	}
+
+	var entries []struct {
+		Key string `json:"key"`
+		Identity
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse api keys file: %w", err)
+	}
+
+	store := &fileKeyStore{keys: make(map[string]Identity, len(entries))}
+	for i, entry := range entries {
+		if entry.Key == "" || entry.User == "" {
+			return nil, fmt.Errorf("api key entry %d must have key and user", i)
+		}
+		store.keys[hashAPIKey(entry.Key)] = entry.Identity
+	}
+	return store, nil
+}
+
+func (s *fileKeyStore) Lookup(ctx context.Context, apiKey string) (Identity, bool, error) {
+	identity, ok := s.keys[hashAPIKey(apiKey)]
+	return identity, ok, nil
+}
+
+// dbKeyStore looks up the API keys in the ai_api_keys table
+type dbKeyStore struct {
+	queries *database.Queries
+}
+
+func (s *dbKeyStore) Lookup(ctx context.Context, apiKey string) (Identity, bool, error) {
+	row, err := s.queries.GetActiveAPIKey(ctx, hashAPIKey(apiKey))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Identity{}, false, nil
+	}
+	if err != nil {
+		return Identity{}, false, err
+	}
+	return Identity{
+		User:   row.Usuario,
+		Name:   row.Nombre,
+		Branch: row.Sucursal,
+		Role:   row.Rol,
+	}, true, nil
+}
+
+// newKeyStore builds the key store selected by the configuration
+func newKeyStore(cfg Config, queries *database.Queries) (KeyStore, error) {
+	switch cfg.APIKeysStore {
+	case "file":
+		return newFileKeyStore(cfg.APIKeysFile)
+	case "db":
+		return &dbKeyStore{queries: queries}, nil
+	default:
+		return nil, fmt.Errorf("unknown API_KEYS_STORE %q, use file or db", cfg.APIKeysStore)
+	}
+}
+
+// hashAPIKey returns the hex SHA-256 of the key, keys are never compared or stored in plain text
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+type identityKey struct{}
+
+func withIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func identityFromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
+
+// requireAPIKey checks the Authorization: Bearer header against the key store
+// and adds the resolved identity to the request context
+func (app *App) requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		apiKey = strings.TrimSpace(apiKey)
+		if !ok || apiKey == "" {
+			writeOpenAIError(w, http.StatusUnauthorized, "invalid_request_error", "missing_api_key",
+				"You didn't provide an API key. Use the Authorization: Bearer header")
+			return
+		}
+
+		identity, ok, err := app.keys.Lookup(r.Context(), apiKey)
+		if err != nil {
+			log.Printf("failed to look up api key: %v\n", err)
+			writeOpenAIError(w, http.StatusInternalServerError, "server_error", "internal_error", "Failed to validate the API key")
+			return
+		}
+		if !ok {
+			log.Printf("rejected unknown api key from %s...\n", r.RemoteAddr)
+			writeOpenAIError(w, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key", "Incorrect API key provided")
+			return
+		}
+
+		next(w, r.WithContext(withIdentity(r.Context(), identity)))
+	}
+}
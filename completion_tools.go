@@ -1,141 +1,151 @@
 package main
 
 import (
+	"context"
 	"copo-ai-agent/internal/database"
-
-	"google.golang.org/genai"
+	"fmt"
+	"time"
 )
 
+// timeNow is the clock of the tools, replaced in tests
+var timeNow = time.Now
+
 type CompletionTools struct {
 	Tools []FunctionTool
 }
 
 type FunctionTool struct {
 	Name        string
-	Declaration *genai.FunctionDeclaration
-	Function    func(*database.Queries, map[string]any) string
+	Declaration *ToolDeclaration
+	Function    func(context.Context, *database.Queries, map[string]any) (any, error)
+	// Offered only to managers, and only in profiles that list the tool
+	ManagerOnly bool
+	// Stores quotes or reservations, replay runs a stub instead
+	Writes bool
 }
 
-func getCompletionTools() CompletionTools {
+func getCompletionTools(cfg Config, policy pricingPolicy) CompletionTools {
 	return CompletionTools{
 		Tools: []FunctionTool{
-			{
-				Name:     "obtenerListaProductos",
-				Function: getCodesList,
-				Declaration: &genai.FunctionDeclaration{
-					Name:        "obtenerListaProductos",
-					Description: "Devuelve un JSON con la lista completa de los productos disponbles. Incluye codigo y descripción del producto",
-					Parameters:  &genai.Schema{Type: genai.TypeObject},
-					Response:    &genai.Schema{Type: genai.TypeString},
-				},
-			},
-			{
-				Name:     "obtenerInformacionPorBusqueda",
-				Function: getProductInfoBySearchTerm,
-				Declaration: &genai.FunctionDeclaration{
-					Name: "obtenerInformacionPorBusqueda",
-					Description: "Hace una búsqueda de productos basado en un término de búsqueda " +
-						"y devuelve un JSON con la información detallada de los productos. El término de búsqueda debe ser una sola palabra en singular.",
-					Parameters: &genai.Schema{
-						Type: genai.TypeObject,
-						Properties: map[string]*genai.Schema{
-							"searchTerm": {
-								Type:        genai.TypeString,
-								Description: "El término para realizar la busqueda. Debe ser una sola palabra en singular",
-							},
-						},
-						Required: []string{"searchTerm"},
-					},
-					Response: &genai.Schema{Type: genai.TypeString},
-				},
-			},
-			{
-				Name:     "obtenerInformacionPorMarca",
-				Function: getProductInfoByBrand,
-				Declaration: &genai.FunctionDeclaration{
-					Name: "obtenerInformacionPorMarca",
-					Description: "Hace una búsqueda de productos por marca " +
-						"y devuelve un JSON con la información detallada de los productos: " +
-						"descripción, línea, sublínea, marca, existencia, popularidad, pesos promedio, " +
-						"piezas por caja, y precios.",
-					Parameters: &genai.Schema{
-						Type: genai.TypeObject,
-						Properties: map[string]*genai.Schema{
-							"brand": {
-								Type:        genai.TypeString,
-								Description: "La marca para realizar la busqueda",
-							},
-						},
-						Required: []string{"brand"},
-					},
-					Response: &genai.Schema{Type: genai.TypeString},
-				},
-			},
-			{
-				Name:     "obtenerInformacionPorLineaSublinea",
-				Function: getProductInfoByCategories,
-				Declaration: &genai.FunctionDeclaration{
-					Name: "obtenerInformacionPorLineaSublinea",
-					Description: "Hace una búsqueda de productos por línea y sublinea " +
-						"y devuelve un JSON con la información detallada de los productos: " +
-						"descripción, línea, sublínea, marca, existencia, popularidad, pesos promedio, " +
-						"piezas por caja, y precios.",
-					Parameters: &genai.Schema{
-						Type: genai.TypeObject,
-						Properties: map[string]*genai.Schema{
-							"linea": {
-								Type:        genai.TypeString,
-								Description: "La linea para realizar la busqueda, si se quieren buscar todas la lineas debe ser un texto vacio ''",
-							},
-							"sublinea": {
-								Type:        genai.TypeString,
-								Description: "La linea para realizar la busqueda, si se quieren buscar todas la sublineas debe ser un texto vacio ''",
-							},
-						},
-						Required: []string{"linea", "sublinea"},
-					},
-					Response: &genai.Schema{Type: genai.TypeString},
-				},
-			},
-			{
-				Name:     "obtenerInformacionPorCodigo",
-				Function: getProductsInfo,
-				Declaration: &genai.FunctionDeclaration{
-					Name: "obtenerInformacionPorCodigo",
-					Description: "Devuelve un JSON con info. detallada de productos por código: " +
-						"descripción, línea, sublínea, marca, existencia, popularidad, pesos promedio, " +
-						"piezas por caja, y precios escalonados.",
-					Parameters: &genai.Schema{
-						Type: genai.TypeObject,
-						Properties: map[string]*genai.Schema{
-							"productCodes": {
-								Type:        genai.TypeArray,
-								Description: "Lista de códigos de productos (strings).",
-								Items:       &genai.Schema{Type: genai.TypeString},
-							},
-						},
-						Required: []string{"productCodes"},
-					},
-					Response: &genai.Schema{Type: genai.TypeString},
-				},
-			},
+			newFunctionTool("obtenerListaProductos",
+				"Devuelve un JSON con la lista completa de los productos disponbles. Incluye codigo y descripción del producto",
+				getCodesList),
+			newFunctionTool("obtenerInformacionPorBusqueda",
+				"Hace una búsqueda de productos basado en un término de búsqueda "+
+					"y devuelve un JSON con la información detallada de los productos. El término de búsqueda debe ser una sola palabra en singular.",
+				getProductInfoBySearchTerm),
+			newFunctionTool("obtenerInformacionPorMarca",
+				"Hace una búsqueda de productos por marca "+
+					"y devuelve un JSON con la información detallada de los productos: "+
+					"descripción, línea, sublínea, marca, existencia, popularidad, pesos promedio, "+
+					"piezas por caja, y precios.",
+				getProductInfoByBrand),
+			newFunctionTool("obtenerInformacionPorLineaSublinea",
+				"Hace una búsqueda de productos por línea y sublinea "+
+					"y devuelve un JSON con la información detallada de los productos: "+
+					"descripción, línea, sublínea, marca, existencia, popularidad, pesos promedio, "+
+					"piezas por caja, y precios.",
+				getProductInfoByCategories),
+			newFunctionTool("obtenerInformacionPorCodigo",
+				"Devuelve un JSON con info. detallada de productos por código: "+
+					"descripción, línea, sublínea, marca, existencia, popularidad, pesos promedio, "+
+					"piezas por caja, y precios escalonados.",
+				getProductsInfo),
+			newFunctionTool("cotizar",
+				"Calcula una cotización exacta de un pedido. Convierte cajas y piezas a kg con los pesos promedio, "+
+					"aplica el precio de detalle, medio mayoreo o mayoreo según los kg de cada producto y "+
+					"devuelve el importe de cada línea, el subtotal, el IVA y el total.",
+				quoteProducts(policy)),
+			saveQuoteTool(cfg, policy),
+			newFunctionTool("buscarCliente",
+				"Busca clientes por número o por parte de su razón social entre los clientes con ventas. "+
+					"Devuelve el número de cliente, la razón social, la fecha de su última compra y su número de compras.",
+				searchCustomers),
+			newFunctionTool("obtenerCondicionesCliente",
+				"Devuelve el nivel de precio y los descuentos que el cliente obtuvo en sus ventas recientes, "+
+					"en general y por producto, con el precio por kg que pagó la última vez.",
+				getCustomerTerms),
+			newFunctionTool("obtenerPreciosCliente",
+				"Calcula el precio por kg de productos para un cliente, con el nivel de precio y los descuentos "+
+					"que obtuvo en su última compra de cada producto o, si no lo ha comprado, sus condiciones habituales.",
+				customerPricesWithPolicy(policy)),
+			newFunctionTool("obtenerHistorialCliente",
+				"Devuelve el historial de compras de un cliente: número de pedidos y días entre pedidos, su último pedido, "+
+					"los productos que compra con más frecuencia con los kg por pedido y los productos que dejó de comprar.",
+				getCustomerHistory),
+			newFunctionTool("sugerirPedido",
+				"Sugiere un pedido para el cliente con sus productos frecuentes, los kg que suele pedir de cada uno, "+
+					"si ya le toca comprarlo y la información actual del producto: existencia, precios y escalas.",
+				suggestReorder),
+			costMarginTool(),
+			reserveStockTool(cfg),
+			extendReservationTool(cfg),
+			releaseReservationTool(),
+			newFunctionTool("obtenerReservas",
+				"Devuelve las reservas de existencia activas del vendedor con su cliente, kg, vencimiento y la existencia disponible del producto.",
+				getReservations),
+			newFunctionTool("obtenerAlertasInventario",
+				"Devuelve por línea los productos con existencia debajo de su mínimo o arriba de su máximo, "+
+					"con la existencia, el mínimo, el máximo, la existencia disponible y los kg que faltan o sobran.",
+				getStockAlerts),
+			newFunctionTool("obtenerExistenciaPorCaducidad",
+				"Devuelve la existencia de productos por fecha de caducidad: los kg por rango de días para caducar "+
+					"y los lotes en existencia con su folio y fecha de entrada, su caducidad y los días que le quedan. "+
+					"Los lotes sin caducidad registrada no traen fecha.",
+				getStockByExpiry),
+			newFunctionTool("sugerirRemate",
+				"Lista los productos con existencia que caduca en los próximos días, con los kg por caducar, la caducidad más próxima, "+
+					"los kg ya vencidos que se deben retirar y el nivel de precio sugerido para venderlos antes de que caduquen.",
+				suggestSelloff(policy)),
+			newFunctionTool(showProductsTool,
+				"Muestra al cliente las tarjetas de los productos elegidos, con precios, escalas y existencia tomados del sistema. "+
+					"Úsala al final, una sola vez, con los códigos de los productos que responden la pregunta. "+
+					"No escribas tú la información de los productos.",
+				showProducts),
 		},
 	}
 }
 
-func (ct *CompletionTools) getDeclarationsList() []*genai.FunctionDeclaration {
-	var listFD []*genai.FunctionDeclaration
+func (ct *CompletionTools) getDeclarationsList() []*ToolDeclaration {
+	var listFD []*ToolDeclaration
 	for _, tool := range ct.Tools {
 		listFD = append(listFD, tool.Declaration)
 	}
 	return listFD
 }
 
-func (ct *CompletionTools) getToolByName(name string) FunctionTool {
+func (ct *CompletionTools) getToolByName(name string) (FunctionTool, bool) {
 	for _, tool := range ct.Tools {
 		if tool.Name == name {
-			return tool
+			return tool, true
+		}
+	}
+	return FunctionTool{}, false
+}
+
+// forRole returns the tools a role may call. Managers get every tool, other roles lose the manager only ones.
+func (ct *CompletionTools) forRole(role string) CompletionTools {
+	if role == roleManager {
+		return *ct
+	}
+	var allowed CompletionTools
+	for _, tool := range ct.Tools {
+		if !tool.ManagerOnly {
+			allowed.Tools = append(allowed.Tools, tool)
+		}
+	}
+	return allowed
+}
+
+// subset returns the tools with the given names, failing on names that are not registered
+func (ct *CompletionTools) subset(names []string) (CompletionTools, error) {
+	var subset CompletionTools
+	for _, name := range names {
+		tool, ok := ct.getToolByName(name)
+		if !ok {
+			return CompletionTools{}, fmt.Errorf("unknown tool %q", name)
 		}
+		subset.Tools = append(subset.Tools, tool)
 	}
-	return FunctionTool{} // Return an empty FunctionTool if not found
+	return subset, nil
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+type Config struct {
+	GeminiKey   string
+	GeminiModel string
+	APIPort     string
+
+	// OpenAI-compatible server (e.g. Ollama at http://localhost:11434/v1) for profiles with provider "openai"
+	OpenAICompatBaseURL string
+	OpenAICompatAPIKey  string
+
+	// Agent profiles config file, and the profile used when a request does not name a model
+	ProfilesFile   string
+	DefaultProfile string
+
+	HistoryTokenBudget int
+
+	// Where the API keys are read from: "file" (APIKeysFile) or "db" (ai_api_keys table)
+	APIKeysStore string
+	APIKeysFile  string
+
+	// JSONL file where the full exchange of every request is saved for replay, empty disables recording
+	RecordingsPath string
+
+	// How long a saved quote keeps its prices, the public URL of the agent used in the quote links
+	// and the key that signs those links
+	QuoteValidity time.Duration
+	PublicBaseURL string
+	QuoteLinkKey  []byte
+
+	// Default and longest duration of a stock reservation
+	ReservationTTL    time.Duration
+	ReservationMaxTTL time.Duration
+
+	// JSON file with the minimum margins of the quotes, empty only guards the mayoreo price and the cost
+	PricingPolicyFile string
+
+	// Time of day (HH:MM) of the daily stock digest, empty disables it, and the notifier that sends it:
+	// "log", "webhook" (NotifierWebhookURL) or "file" (NotifierFile)
+	StockDigestAt      string
+	Notifier           string
+	NotifierWebhookURL string
+	NotifierFile       string
+
+	// JSONL file where the token usage of every request is appended, empty keeps it only in memory
+	UsageLedgerPath string
+
+	// Overall budget for a chat request, and deadlines for each Gemini call and each database query
+	RequestTimeout time.Duration
+	LLMTimeout     time.Duration
+	DBQueryTimeout time.Duration
+
+	// Connection pool limits for the MariaDB database
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+}
+
+func loadConfig() (Config, error) {
+	err := godotenv.Load()
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load .env file: %w", err)
+	}
+
+	cfg := Config{
+		GeminiKey:   os.Getenv("GEMINI_API_KEY"),
+		GeminiModel: os.Getenv("GEMINI_MODEL"),
+		APIPort:     fmt.Sprintf(":%v", os.Getenv("API_PORT")),
+
+		OpenAICompatBaseURL: os.Getenv("OPENAI_COMPAT_BASE_URL"),
+		OpenAICompatAPIKey:  os.Getenv("OPENAI_COMPAT_API_KEY"),
+
+		ProfilesFile:    envString("PROFILES_FILE", "profiles.json"),
+		DefaultProfile:  envString("DEFAULT_PROFILE", "COPO-AI"),
+		APIKeysStore:    envString("API_KEYS_STORE", "file"),
+		APIKeysFile:     envString("API_KEYS_FILE", "api_keys.json"),
+		RecordingsPath:  os.Getenv("RECORDINGS_PATH"),
+		UsageLedgerPath: os.Getenv("USAGE_LEDGER_PATH"),
+		PublicBaseURL:   os.Getenv("PUBLIC_BASE_URL"),
+		QuoteLinkKey:    []byte(os.Getenv("QUOTE_LINK_SECRET")),
+
+		PricingPolicyFile: os.Getenv("PRICING_POLICY_FILE"),
+
+		StockDigestAt:      os.Getenv("STOCK_DIGEST_AT"),
+		Notifier:           envString("NOTIFIER", "log"),
+		NotifierWebhookURL: os.Getenv("NOTIFIER_WEBHOOK_URL"),
+		NotifierFile:       os.Getenv("NOTIFIER_FILE"),
+	}
+
+	if cfg.HistoryTokenBudget, err = envInt("HISTORY_TOKEN_BUDGET", defaultHistoryTokenBudget); err != nil {
+		return Config{}, err
+	}
+	if cfg.RequestTimeout, err = envDuration("REQUEST_TIMEOUT", 2*time.Minute); err != nil {
+		return Config{}, err
+	}
+	if cfg.LLMTimeout, err = envDuration("LLM_TIMEOUT", 60*time.Second); err != nil {
+		return Config{}, err
+	}
+	if cfg.DBQueryTimeout, err = envDuration("DB_QUERY_TIMEOUT", 10*time.Second); err != nil {
+		return Config{}, err
+	}
+	if cfg.QuoteValidity, err = envDuration("QUOTE_VALIDITY", 48*time.Hour); err != nil {
+		return Config{}, err
+	}
+	if len(cfg.QuoteLinkKey) == 0 {
+		log.Println("QUOTE_LINK_SECRET is not set, the quote links will stop working on restart...")
+		cfg.QuoteLinkKey = make([]byte, 32)
+		rand.Read(cfg.QuoteLinkKey)
+	}
+	if cfg.ReservationTTL, err = envDuration("RESERVATION_TTL", 24*time.Hour); err != nil {
+		return Config{}, err
+	}
+	if cfg.ReservationMaxTTL, err = envDuration("RESERVATION_MAX_TTL", 72*time.Hour); err != nil {
+		return Config{}, err
+	}
+	if cfg.ReservationTTL > cfg.ReservationMaxTTL {
+		return Config{}, fmt.Errorf("RESERVATION_TTL can not be longer than RESERVATION_MAX_TTL")
+	}
+	if cfg.StockDigestAt != "" {
+		if _, err := parseDigestTime(cfg.StockDigestAt); err != nil {
+			return Config{}, err
+		}
+	}
+	if cfg.DBMaxOpenConns, err = envInt("DB_MAX_OPEN_CONNS", 10); err != nil {
+		return Config{}, err
+	}
+	if cfg.DBMaxIdleConns, err = envInt("DB_MAX_IDLE_CONNS", 5); err != nil {
+		return Config{}, err
+	}
+	if cfg.DBConnMaxLifetime, err = envDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute); err != nil {
+		return Config{}, err
+	}
+	if cfg.DBConnMaxIdleTime, err = envDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// envString reads a string environment variable, using fallback when it is not set
+func envString(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envInt reads an integer environment variable, using fallback when it is not set
+func envInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// envDuration reads a duration environment variable (e.g. "30s", "5m"), using fallback when it is not set
+func envDuration(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Default token budget for the conversation history sent to the model
+const defaultHistoryTokenBudget = 8000
+
+type conversation struct {
+	// Extra instructions from system messages, appended to the system prompt
+	SystemInstructions string `json:"system_instructions,omitempty"`
+	// Previous turns, oldest first
+	History []LLMMessage `json:"history,omitempty"`
+	// Latest user message
+	UserQuery string `json:"user_query"`
+}
+
+// buildConversation converts the OpenAI message list into a provider-neutral conversation.
+// The last message must come from the user; older turns are trimmed so that
+// the history fits inside tokenBudget.
+func buildConversation(messages []OpenAIMessage, tokenBudget int) (conversation, error) {
+	var conv conversation
+	if len(messages) == 0 {
+		return conv, fmt.Errorf("no messages in request")
+	}
+
+	last := messages[len(messages)-1]
+	if last.Role != "user" {
+		return conv, fmt.Errorf("last message must have role user, got %q", last.Role)
+	}
+	conv.UserQuery = last.Content
+
+	var systemParts []string
+	var turns []LLMMessage
+	for _, msg := range messages[:len(messages)-1] {
+		switch msg.Role {
+		case "system", "developer":
+			if text := strings.TrimSpace(msg.Content); text != "" {
+				systemParts = append(systemParts, text)
+			}
+		case "user":
+			turns = appendTurn(turns, llmRoleUser, msg.Content)
+		case "assistant":
+			turns = appendTurn(turns, llmRoleAssistant, stripResponseFormat(msg.Content))
+		default:
+			return conv, fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+	conv.SystemInstructions = strings.Join(systemParts, "\n")
+
+	budget := tokenBudget - estimateTokens(conv.UserQuery) - estimateTokens(conv.SystemInstructions)
+	conv.History = trimHistory(turns, budget)
+
+	return conv, nil
+}
+
+// appendTurn adds a message to the history, merging consecutive messages from the same role
+func appendTurn(turns []LLMMessage, role, text string) []LLMMessage {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return turns
+	}
+	if len(turns) > 0 && turns[len(turns)-1].Role == role {
+		turns[len(turns)-1].Text += "\n\n" + text
+		return turns
+	}
+	return append(turns, LLMMessage{Role: role, Text: text})
+}
+
+// trimHistory keeps the most recent turns that fit in the token budget.
+// The kept history always starts with a user turn.
+func trimHistory(turns []LLMMessage, budget int) []LLMMessage {
+	start := len(turns)
+	used := 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		tokens := estimateTokens(turns[i].Text)
+		if used+tokens > budget {
+			break
+		}
+		used += tokens
+		start = i
+	}
+
+	for start < len(turns) && turns[start].Role != llmRoleUser {
+		start++
+	}
+	return turns[start:]
+}
+
+// estimateTokens approximates the token count of a text (~4 characters per token)
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// stripResponseFormat removes the header and footer added by the whatsapp formatter,
+// they carry no information for the model and only use up the history budget
+func stripResponseFormat(text string) string {
+	text = strings.TrimPrefix(strings.TrimSpace(text), responseHeader)
+	text = strings.TrimSuffix(strings.TrimSpace(text), responseFooter)
+	return strings.TrimSpace(text)
+}
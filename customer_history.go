@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"math"
+	"strings"
+	"time"
+)
+
+// Days of purchases read when the model does not ask for a period
+const defaultPurchaseHistoryDays = 180
+
+// A product the customer bought at least twice is dropped when they have gone longer than
+// droppedProductIntervals times its usual interval without buying it, and at least minDroppedDays
+const (
+	droppedProductIntervals = 2
+	minDroppedDays          = 30
+)
+
+// Products listed as frequent in the history and the reorder suggestion
+const maxFrequentProducts = 15
+
+type customerHistoryArgs struct {
+	NumeroCliente string `json:"numeroCliente" desc:"Número de cliente obtenido con buscarCliente."`
+	Dias          int    `json:"dias,omitempty" desc:"Días de compras a revisar, 180 si no se indica."`
+}
+
+type customerOrder struct {
+	Folio     int32
+	Fecha     string
+	Renglones int64
+	Kg        float64
+	Importe   float64
+}
+
+// customerProduct sums up the purchases of a product by the customer in the period
+type customerProduct struct {
+	Codigo        string
+	Descripcion   string
+	Pedidos       int64
+	Kg            float64
+	KgPorPedido   float64
+	Importe       float64
+	PrimeraCompra string
+	UltimaCompra  string
+	// Average days between purchases of the product, 0 if it was bought once
+	DiasEntreCompras float64
+	DiasSinComprar   int
+}
+
+type customerHistory struct {
+	NumeroCliente string
+	RazonSocial   string
+	Desde         string
+	Pedidos       int
+	// Average days between orders, 0 with a single order
+	DiasEntrePedidos    float64
+	UltimoPedido        customerOrder
+	ProductosFrecuentes []customerProduct
+	ProductosDejados    []customerProduct
+}
+
+func getCustomerHistory(ctx context.Context, queries *database.Queries, args customerHistoryArgs) (any, error) {
+	return loadCustomerHistory(ctx, queries, args.NumeroCliente, args.Dias)
+}
+
+// loadCustomerHistory reads the orders and the products a customer bought in the last days
+func loadCustomerHistory(ctx context.Context, queries *database.Queries, numeroCliente string, days int) (customerHistory, error) {
+	numeroCliente = strings.TrimSpace(numeroCliente)
+	if numeroCliente == "" {
+		return customerHistory{}, invalidArgumentError("numeroCliente no puede estar vacío")
+	}
+	if days < 0 {
+		return customerHistory{}, invalidArgumentError("dias debe ser mayor a cero")
+	}
+	if days == 0 {
+		days = defaultPurchaseHistoryDays
+	}
+
+	today := timeNow()
+	params := database.GetCustomerOrdersParams{
+		NumeroCliente: numeroCliente,
+		Desde:         today.AddDate(0, 0, -days).Format("2006-01-02"),
+	}
+	orders, err := queries.GetCustomerOrders(ctx, params)
+	if err != nil {
+		return customerHistory{}, executionError("ocurrió un error al obtener los pedidos del cliente", err)
+	}
+	if len(orders) == 0 {
+		return customerHistory{}, invalidArgumentError("el cliente %s no tiene compras en los últimos %d días, búscalo con buscarCliente",
+			numeroCliente, days)
+	}
+	products, err := queries.GetCustomerProductHistory(ctx, database.GetCustomerProductHistoryParams(params))
+	if err != nil {
+		return customerHistory{}, executionError("ocurrió un error al obtener los productos del cliente", err)
+	}
+
+	last := orders[0]
+	history := customerHistory{
+		NumeroCliente: numeroCliente,
+		RazonSocial:   last.RazonSocial,
+		Desde:         params.Desde,
+		Pedidos:       len(orders),
+		UltimoPedido: customerOrder{
+			Folio:     last.Folio,
+			Fecha:     last.Fecha,
+			Renglones: last.Renglones,
+			Kg:        roundCents(last.Kg),
+			Importe:   roundCents(last.Importe),
+		},
+	}
+	if len(orders) > 1 {
+		history.DiasEntrePedidos = roundCents(daysBetween(orders[len(orders)-1].Fecha, last.Fecha) / float64(len(orders)-1))
+	}
+
+	for _, row := range products {
+		product := customerProduct{
+			Codigo:         row.Codigo,
+			Descripcion:    row.Descripcion,
+			Pedidos:        row.Pedidos,
+			Kg:             roundCents(row.Kg),
+			KgPorPedido:    roundCents(row.Kg / float64(row.Pedidos)),
+			Importe:        roundCents(row.Importe),
+			PrimeraCompra:  row.PrimeraCompra,
+			UltimaCompra:   row.UltimaCompra,
+			DiasSinComprar: int(daysBetween(row.UltimaCompra, today.Format("2006-01-02"))),
+		}
+		if row.Pedidos > 1 {
+			product.DiasEntreCompras = roundCents(daysBetween(row.PrimeraCompra, row.UltimaCompra) / float64(row.Pedidos-1))
+		}
+
+		switch {
+		case product.dropped():
+			history.ProductosDejados = append(history.ProductosDejados, product)
+		case len(history.ProductosFrecuentes) < maxFrequentProducts:
+			history.ProductosFrecuentes = append(history.ProductosFrecuentes, product)
+		}
+	}
+	return history, nil
+}
+
+func (p customerProduct) dropped() bool {
+	return p.Pedidos > 1 && float64(p.DiasSinComprar) > math.Max(minDroppedDays, droppedProductIntervals*p.DiasEntreCompras)
+}
+
+// daysBetween returns the days from one Vfecham date to another, 0 if either can not be read
+func daysBetween(from, to string) float64 {
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return 0
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return 0
+	}
+	return math.Round(end.Sub(start).Hours() / 24)
+}
+
+// reorderLine is a frequent product with the kg the customer usually orders and its current
+// information: stock, prices and scales as obtenerInformacionPorCodigo returns them
+type reorderLine struct {
+	Codigo           string
+	Descripcion      string
+	KgSugeridos      float64
+	Pedidos          int64
+	UltimaCompra     string
+	DiasSinComprar   int
+	DiasEntreCompras float64
+	// The usual interval has passed since the last purchase
+	LeToca     bool
+	InfoActual database.GetProductsInfoByCodeRow
+}
+
+type reorderSuggestion struct {
+	NumeroCliente string
+	RazonSocial   string
+	UltimoPedido  customerOrder
+	Productos     []reorderLine
+	// Frequent products that are no longer in the catalog
+	SinInformacion []string
+}
+
+// suggestReorder builds a reorder for the customer from their frequent products, with the
+// current stock and prices so the model does not need to look them up again
+func suggestReorder(ctx context.Context, queries *database.Queries, args customerArgs) (any, error) {
+	history, err := loadCustomerHistory(ctx, queries, args.NumeroCliente, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	for _, product := range history.ProductosFrecuentes {
+		codes = append(codes, product.Codigo)
+	}
+	rows, err := fetchProductsInfo(ctx, queries, codes)
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]database.GetProductsInfoByCodeRow{}
+	for _, row := range rows {
+		info[row.Codigo] = row
+	}
+
+	suggestion := reorderSuggestion{
+		NumeroCliente: history.NumeroCliente,
+		RazonSocial:   history.RazonSocial,
+		UltimoPedido:  history.UltimoPedido,
+	}
+	for _, product := range history.ProductosFrecuentes {
+		current, ok := info[product.Codigo]
+		if !ok {
+			suggestion.SinInformacion = append(suggestion.SinInformacion, product.Codigo)
+			continue
+		}
+		suggestion.Productos = append(suggestion.Productos, reorderLine{
+			Codigo:           product.Codigo,
+			Descripcion:      product.Descripcion,
+			KgSugeridos:      product.KgPorPedido,
+			Pedidos:          product.Pedidos,
+			UltimaCompra:     product.UltimaCompra,
+			DiasSinComprar:   product.DiasSinComprar,
+			DiasEntreCompras: product.DiasEntreCompras,
+			LeToca:           product.DiasEntreCompras > 0 && float64(product.DiasSinComprar) >= product.DiasEntreCompras,
+			InfoActual:       current,
+		})
+	}
+	return suggestion, nil
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Days of sales read to find the price level and discounts a customer gets
+const customerHistoryDays = 90
+
+type customerSearchArgs struct {
+	Busqueda string `json:"busqueda" desc:"Número de cliente o parte de su razón social, por ejemplo 'lopez'."`
+}
+
+// searchCustomers finds customers by number or name among the ones with sales
+func searchCustomers(ctx context.Context, queries *database.Queries, args customerSearchArgs) (any, error) {
+	busqueda := strings.TrimSpace(args.Busqueda)
+	if busqueda == "" {
+		return nil, invalidArgumentError("busqueda no puede estar vacía")
+	}
+
+	customers, err := queries.SearchCustomers(ctx, busqueda)
+	if err != nil {
+		return nil, executionError("ocurrió un error al buscar el cliente", err)
+	}
+	if len(customers) == 0 {
+		return nil, invalidArgumentError("no se encontró ningún cliente con %q, pide al usuario el número de cliente", busqueda)
+	}
+	return customers, nil
+}
+
+type customerArgs struct {
+	NumeroCliente string `json:"numeroCliente" desc:"Número de cliente obtenido con buscarCliente."`
+}
+
+// customerTerms are the price levels and discounts a customer actually got in recent sales
+type customerTerms struct {
+	NumeroCliente string
+	RazonSocial   string
+	Desde         string
+	Ventas        int
+	// Most used price level and discounts, applied to the products the customer has not bought
+	NivelPrecio int
+	Lista       string
+	Descuentos  []float64
+	Niveles     []priceLevelUse
+	Productos   []customerProductTerms
+}
+
+type priceLevelUse struct {
+	NivelPrecio int
+	Lista       string
+	Renglones   int
+}
+
+// customerProductTerms are the terms of the last sale of a product to the customer
+type customerProductTerms struct {
+	Codigo         string
+	Descripcion    string
+	UltimaCompra   string
+	NivelPrecio    int
+	Lista          string
+	PrecioLista    float64
+	Descuentos     []float64
+	PrecioPagadoKg float64
+}
+
+func getCustomerTerms(ctx context.Context, queries *database.Queries, args customerArgs) (any, error) {
+	return loadCustomerTerms(ctx, queries, args.NumeroCliente)
+}
+
+// loadCustomerTerms reads the recent sales of a customer, newest first, and sums up the price
+// levels and the discounts (Vdto001 to Vdto010) they got
+func loadCustomerTerms(ctx context.Context, queries *database.Queries, numeroCliente string) (customerTerms, error) {
+	numeroCliente = strings.TrimSpace(numeroCliente)
+	if numeroCliente == "" {
+		return customerTerms{}, invalidArgumentError("numeroCliente no puede estar vacío")
+	}
+
+	desde := timeNow().AddDate(0, 0, -customerHistoryDays).Format("2006-01-02")
+	sales, err := queries.GetCustomerSales(ctx, database.GetCustomerSalesParams{NumeroCliente: numeroCliente, Desde: desde})
+	if err != nil {
+		return customerTerms{}, executionError("ocurrió un error al obtener las ventas del cliente", err)
+	}
+	if len(sales) == 0 {
+		return customerTerms{}, invalidArgumentError("el cliente %s no tiene ventas en los últimos %d días, búscalo con buscarCliente",
+			numeroCliente, customerHistoryDays)
+	}
+
+	terms := customerTerms{
+		NumeroCliente: numeroCliente,
+		RazonSocial:   sales[0].RazonSocial,
+		Desde:         desde,
+	}
+	folios := map[int32]bool{}
+	levels := map[int]*priceLevelUse{}
+	var levelKeys, discountKeys []string
+	discountSets := map[string][]float64{}
+	seen := map[string]bool{}
+	for _, sale := range sales {
+		folios[sale.Folio] = true
+
+		level := parsePriceLevel(sale.NivelPrecio)
+		if levels[level] == nil {
+			levels[level] = &priceLevelUse{NivelPrecio: level, Lista: priceLevelName(level)}
+		}
+		levels[level].Renglones++
+		levelKeys = append(levelKeys, strconv.Itoa(level))
+
+		discounts := saleDiscounts(sale)
+		key := fmt.Sprint(discounts)
+		discountSets[key] = discounts
+		discountKeys = append(discountKeys, key)
+
+		if seen[sale.Codigo] {
+			continue
+		}
+		seen[sale.Codigo] = true
+		product := customerProductTerms{
+			Codigo:       sale.Codigo,
+			Descripcion:  sale.Descripcion,
+			UltimaCompra: sale.Fecha,
+			NivelPrecio:  level,
+			Lista:        priceLevelName(level),
+			PrecioLista:  sale.PrecioLista,
+			Descuentos:   discounts,
+		}
+		if sale.Cantidad > 0 {
+			product.PrecioPagadoKg = roundCents(sale.Importe / sale.Cantidad)
+		}
+		terms.Productos = append(terms.Productos, product)
+	}
+
+	terms.Ventas = len(folios)
+	terms.NivelPrecio, _ = strconv.Atoi(mostFrequent(levelKeys))
+	terms.Lista = priceLevelName(terms.NivelPrecio)
+	terms.Descuentos = discountSets[mostFrequent(discountKeys)]
+	for level := 1; level <= 6; level++ {
+		if use, ok := levels[level]; ok {
+			terms.Niveles = append(terms.Niveles, *use)
+		}
+	}
+	return terms, nil
+}
+
+type customerPricesArgs struct {
+	NumeroCliente string   `json:"numeroCliente" desc:"Número de cliente obtenido con buscarCliente."`
+	ProductCodes  []string `json:"productCodes" desc:"Lista de códigos de productos (strings)."`
+}
+
+// customerPrice is the price per kg of a product for the customer, at the level and with
+// the discounts of their last purchase of the product or, if they never bought it, their usual ones
+type customerPrice struct {
+	Codigo        string
+	Descripcion   string
+	NivelPrecio   int
+	Lista         string
+	PrecioNivel   float64
+	Descuentos    []float64
+	PrecioCliente float64
+	Origen        string
+	// Last price per kg the customer paid for the product, 0 if they never bought it
+	UltimoPrecioPagado float64
+	UltimaCompra       string
+}
+
+type customerPrices struct {
+	NumeroCliente string
+	RazonSocial   string
+	Precios       []customerPrice
+	// Customer prices that need a manager approval to be quoted
+	FueraDePolitica []policyViolation
+}
+
+// customerPricesWithPolicy flags the customer prices that break the pricing policy, the
+// discounts of a past sale may leave a price under the cost
+func customerPricesWithPolicy(policy pricingPolicy) func(context.Context, *database.Queries, customerPricesArgs) (any, error) {
+	return func(ctx context.Context, queries *database.Queries, args customerPricesArgs) (any, error) {
+		prices, err := loadCustomerPrices(ctx, queries, args)
+		if err != nil {
+			return nil, err
+		}
+		var proposed []policyPrice
+		for _, price := range prices.Precios {
+			proposed = append(proposed, policyPrice{Codigo: price.Codigo, Precio: price.PrecioCliente})
+		}
+		if prices.FueraDePolitica, err = policy.check(ctx, queries, proposed); err != nil {
+			return nil, err
+		}
+		return prices, nil
+	}
+}
+
+// loadCustomerPrices prices the products for the customer from the terms of their recent sales
+func loadCustomerPrices(ctx context.Context, queries *database.Queries, args customerPricesArgs) (customerPrices, error) {
+	if len(args.ProductCodes) == 0 {
+		return customerPrices{}, invalidArgumentError("productCodes debe incluir al menos un código")
+	}
+
+	terms, err := loadCustomerTerms(ctx, queries, args.NumeroCliente)
+	if err != nil {
+		return customerPrices{}, err
+	}
+
+	rows, err := queries.GetProductPriceLevels(ctx, args.ProductCodes)
+	if err != nil {
+		return customerPrices{}, executionError("ocurrió un error al obtener los precios de los productos", err)
+	}
+	products := map[string]database.GetProductPriceLevelsRow{}
+	for _, row := range rows {
+		products[row.Codigo] = row
+	}
+	bought := map[string]customerProductTerms{}
+	for _, product := range terms.Productos {
+		bought[product.Codigo] = product
+	}
+
+	result := customerPrices{NumeroCliente: terms.NumeroCliente, RazonSocial: terms.RazonSocial}
+	var missing []string
+	for _, code := range args.ProductCodes {
+		product, ok := products[code]
+		if !ok {
+			missing = append(missing, code)
+			continue
+		}
+
+		price := customerPrice{
+			Codigo:      product.Codigo,
+			Descripcion: product.Descripcion,
+			NivelPrecio: terms.NivelPrecio,
+			Descuentos:  terms.Descuentos,
+			Origen:      "condiciones habituales del cliente",
+		}
+		if last, ok := bought[code]; ok {
+			price.NivelPrecio = last.NivelPrecio
+			price.Descuentos = last.Descuentos
+			price.Origen = "última compra del producto"
+			price.UltimoPrecioPagado = last.PrecioPagadoKg
+			price.UltimaCompra = last.UltimaCompra
+		}
+
+		// A level without price falls back to the previous one, as the Grupo tiers do
+		for price.NivelPrecio > 1 && levelPrice(product, price.NivelPrecio) <= 0 {
+			price.NivelPrecio--
+		}
+		price.Lista = priceLevelName(price.NivelPrecio)
+		price.PrecioNivel = levelPrice(product, price.NivelPrecio)
+		if price.PrecioNivel <= 0 {
+			return customerPrices{}, invalidArgumentError("el producto %s no tiene precio registrado", code)
+		}
+		price.PrecioCliente = applyDiscounts(price.PrecioNivel, price.Descuentos)
+		result.Precios = append(result.Precios, price)
+	}
+	if len(missing) > 0 {
+		return customerPrices{}, invalidArgumentError("no se encontraron los códigos %s", strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+// parsePriceLevel reads Vnivpre, the Grupo price level (Fac1 to Fac6) of a sale. Sales without
+// a valid level were made at the detalle price.
+func parsePriceLevel(value string) int {
+	level, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || level < 1 || level > 6 {
+		return 1
+	}
+	return level
+}
+
+func priceLevelName(level int) string {
+	switch level {
+	case 1:
+		return priceListRetail
+	case 2:
+		return priceListHalfSale
+	case 3:
+		return priceListWholesale
+	}
+	return fmt.Sprintf("nivel %d", level)
+}
+
+func levelPrice(product database.GetProductPriceLevelsRow, level int) float64 {
+	return [...]float64{product.Fac1, product.Fac2, product.Fac3, product.Fac4, product.Fac5, product.Fac6}[level-1]
+}
+
+// saleDiscounts returns the discounts in percent of a sale line, in the order they are applied
+func saleDiscounts(sale database.GetCustomerSalesRow) []float64 {
+	discounts := []float64{}
+	for _, d := range []float64{
+		sale.Vdto001, sale.Vdto002, sale.Vdto003, sale.Vdto004, sale.Vdto005,
+		sale.Vdto006, sale.Vdto007, sale.Vdto008, sale.Vdto009, sale.Vdto010,
+	} {
+		if d != 0 {
+			discounts = append(discounts, d)
+		}
+	}
+	return discounts
+}
+
+// applyDiscounts applies the discounts one after the other, as the ERP does
+func applyDiscounts(price float64, discounts []float64) float64 {
+	for _, d := range discounts {
+		price *= 1 - d/100
+	}
+	return roundCents(price)
+}
+
+// mostFrequent returns the most repeated key, on a tie the first one to reach the count
+func mostFrequent(keys []string) string {
+	counts := map[string]int{}
+	best := ""
+	for _, key := range keys {
+		counts[key]++
+		if best == "" || counts[key] > counts[best] {
+			best = key
+		}
+	}
+	return best
+}
@@ -0,0 +1,639 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// testProduct is a row of the in-memory catalog, joining articulos, lineas and grupos
+type testProduct struct {
+	Codigo             string
+	Descripcion        string
+	Linea              string
+	Sublinea           string
+	Marca              string
+	ExistenciaKg       float64
+	PesoPromedioCajaKg float64
+	PiezasPorCaja      int64
+	PrecioDetalle      float64
+	EscalaDetalle      string
+	PrecioMedioMayoreo float64
+	EscalaMedioMayoreo string
+	PrecioMayoreo      float64
+	TasaIva            float64
+	CostoUltimo        float64
+	CostoAnterior      float64
+	MinimoKg           float64
+	MaximoKg           float64
+	DisponibleKg       float64
+}
+
+// 102 is below its minimum and 205 above its maximum
+var testCatalog = []testProduct{
+	{"101", "PECHUGA DE POLLO", "POLLO", "FRESCO", "BACHOCO", 120.5, 20, 10, 95.5, "30", 92, "100", 89, 0, 81.2, 79.9, 80, 300, 110.5},
+	{"102", "PIERNA Y MUSLO DE POLLO", "POLLO", "FRESCO", "BACHOCO", 80, 18, 24, 62, "30", 59.5, "100", 57, 0, 0, 0, 100, 400, 80},
+	{"205", "SALCHICHA DE PAVO", "EMBUTIDOS", "SALCHICHAS", "FUD", 45.25, 10, 40, 78, "20", 75, "60", 72.5, 16, 61.75, 60.4, 10, 40, 45.25},
+}
+
+// testSale is a sale line of movimientosd. testSales is sorted newest first, as GetCustomerSales returns it.
+type testSale struct {
+	Folio       int32
+	Fecha       string
+	Cliente     string
+	RazonSocial string
+	Codigo      string
+	Descripcion string
+	Cantidad    float64
+	Importe     float64
+	NivelPrecio string
+	PrecioLista float64
+	Descuentos  [10]float64
+}
+
+var testSales = []testSale{
+	{5012, "2025-03-04", "C-118", "CARNICERIA LOPEZ", "101", "PECHUGA DE POLLO", 40, 3569.6, "2", 92, [10]float64{3}},
+	{5012, "2025-03-04", "C-118", "CARNICERIA LOPEZ", "205", "SALCHICHA DE PAVO", 10, 750, "2", 75, [10]float64{}},
+	{4999, "2025-03-01", "C-240", "POLLERIA LOPEZ", "102", "PIERNA Y MUSLO DE POLLO", 120, 6840, "3", 57, [10]float64{}},
+	{4870, "2025-02-11", "C-118", "CARNICERIA LOPEZ", "101", "PECHUGA DE POLLO", 25, 2387.5, "1", 95.5, [10]float64{}},
+	{4511, "2024-10-02", "C-118", "CARNICERIA LOPEZ", "102", "PIERNA Y MUSLO DE POLLO", 30, 1860, "1", 62, [10]float64{}},
+	{4390, "2024-09-20", "C-118", "CARNICERIA LOPEZ", "102", "PIERNA Y MUSLO DE POLLO", 30, 1860, "1", 62, [10]float64{}},
+}
+
+// testLot is an entry (ent01) of movimientosd with its expiry date, empty when the entry has none.
+// The stock of every product is its newest entries: 80 + 40.5 kg of 101 expire in 4 and 1 days,
+// 30 kg of 205 expire in 5 days and 15.25 kg expired yesterday, 102 has no expiry date.
+type testLot struct {
+	Folio     int32
+	Fecha     string
+	Codigo    string
+	Kg        float64
+	Caducidad string
+}
+
+var testLots = []testLot{
+	{7310, "2025-03-08", "101", 80, "2025-03-14"},
+	{7308, "2025-03-07", "205", 30, "2025-03-15"},
+	{7305, "2025-03-05", "102", 50, ""},
+	{7301, "2025-03-03", "101", 60, "2025-03-11"},
+	{7295, "2025-02-25", "205", 30, "2025-03-09"},
+	{7288, "2025-02-20", "101", 50, "2025-02-28"},
+}
+
+func (l testLot) expiry() time.Time {
+	expiry, _ := time.Parse(time.DateOnly, l.Caducidad)
+	return expiry
+}
+
+// queryHandler answers a sqlc query from the in-memory catalog
+type queryHandler func(args []driver.NamedValue) (columns []string, rows [][]driver.Value, err error)
+
+// execHandler runs a sqlc :exec or :execresult statement against the in-memory tables
+type execHandler func(args []driver.NamedValue) (driver.Result, error)
+
+// fakeDB is an in-memory database.DBTX. Queries are dispatched by the sqlc
+// "-- name: X" comment at the start of every generated query.
+type fakeDB struct {
+	mu       sync.Mutex
+	handlers map[string]queryHandler
+	execs    map[string]execHandler
+	tables   map[string]*fakeTable
+}
+
+func newFakeDB(products []testProduct) *sql.DB {
+	db := &fakeDB{
+		handlers: catalogHandlers(products, testSales, testLots),
+		execs:    map[string]execHandler{},
+		tables:   map[string]*fakeTable{},
+	}
+	db.registerTableHandlers(products)
+	return sql.OpenDB(db)
+}
+
+// fakeTable holds the rows written by the agent, with an auto increment id in the first column
+type fakeTable struct {
+	rows   [][]driver.Value
+	lastID int64
+}
+
+func (db *fakeDB) table(name string) *fakeTable {
+	if db.tables[name] == nil {
+		db.tables[name] = &fakeTable{}
+	}
+	return db.tables[name]
+}
+
+func (t *fakeTable) insert(args []driver.NamedValue) driver.Result {
+	t.lastID++
+	row := []driver.Value{t.lastID}
+	for _, arg := range args {
+		row = append(row, arg.Value)
+	}
+	t.rows = append(t.rows, row)
+	return fakeResult{lastID: t.lastID, rowsAffected: 1}
+}
+
+// selectWhere returns the rows whose column equals value
+func (t *fakeTable) selectWhere(column int, value driver.Value) [][]driver.Value {
+	var rows [][]driver.Value
+	for _, row := range t.rows {
+		if row[column] == value {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (t *fakeTable) deleteWhere(column int, value driver.Value) {
+	t.rows = slices.DeleteFunc(t.rows, func(row []driver.Value) bool { return row[column] == value })
+}
+
+type fakeResult struct {
+	lastID       int64
+	rowsAffected int64
+}
+
+func (r fakeResult) LastInsertId() (int64, error) { return r.lastID, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// registerTableHandlers answers the queries on the tables owned by the agent
+func (db *fakeDB) registerTableHandlers(products []testProduct) {
+	quoteColumns := []string{"id", "cliente", "notas", "usuario", "sucursal", "subtotal", "iva", "total", "creada", "vence"}
+	lineColumns := []string{"id", "quote_id", "codigo", "descripcion", "cantidad", "unidad", "kg", "lista", "precio_kg", "importe", "tasa_iva", "iva"}
+	approvalColumns := []string{"id", "quote_id", "usuario", "motivos", "estado", "revisor", "comentario", "creada", "resuelta", "notificada"}
+	reservationColumns := []string{"id", "codigo", "descripcion", "kg", "cliente", "numero_cliente", "usuario", "sucursal", "creada", "vence", "liberada"}
+
+	db.execs["CreateQuote"] = func(args []driver.NamedValue) (driver.Result, error) {
+		return db.table("ai_quotes").insert(args), nil
+	}
+	db.execs["CreateQuoteLine"] = func(args []driver.NamedValue) (driver.Result, error) {
+		return db.table("ai_quote_lines").insert(args), nil
+	}
+	db.execs["DeleteQuote"] = func(args []driver.NamedValue) (driver.Result, error) {
+		db.table("ai_quotes").deleteWhere(0, args[0].Value)
+		db.table("ai_quote_lines").deleteWhere(1, args[0].Value)
+		db.table("ai_quote_approvals").deleteWhere(1, args[0].Value)
+		return fakeResult{}, nil
+	}
+	db.execs["CreateQuoteApproval"] = func(args []driver.NamedValue) (driver.Result, error) {
+		// quote_id, usuario, motivos and creada, with the defaults of the other columns
+		row := []driver.NamedValue{args[0], args[1], args[2], {Value: "pendiente"}, {Value: ""}, {Value: ""}, args[3], {Value: nil}, {Value: false}}
+		return db.table("ai_quote_approvals").insert(row), nil
+	}
+	db.execs["ResolveQuoteApproval"] = func(args []driver.NamedValue) (driver.Result, error) {
+		var affected int64
+		for _, row := range db.table("ai_quote_approvals").selectWhere(0, args[4].Value) {
+			if row[4] == "pendiente" {
+				row[4], row[5], row[6], row[8] = args[0].Value, args[1].Value, args[2].Value, args[3].Value
+				affected++
+			}
+		}
+		return fakeResult{rowsAffected: affected}, nil
+	}
+	db.execs["MarkApprovalNotified"] = func(args []driver.NamedValue) (driver.Result, error) {
+		for _, row := range db.table("ai_quote_approvals").selectWhere(0, args[0].Value) {
+			row[9] = true
+		}
+		return fakeResult{rowsAffected: 1}, nil
+	}
+	db.handlers["GetQuoteApproval"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return approvalColumns, db.table("ai_quote_approvals").selectWhere(0, args[0].Value), nil
+	}
+	db.handlers["GetQuoteApprovalByQuote"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		rows := db.table("ai_quote_approvals").selectWhere(1, args[0].Value)
+		if len(rows) > 1 {
+			rows = rows[len(rows)-1:]
+		}
+		return approvalColumns, rows, nil
+	}
+	// A reservation is active while liberada is NULL and vence is after the time in the query
+	active := func(row []driver.Value, now driver.Value) bool {
+		return row[10] == nil && row[9].(time.Time).After(now.(time.Time))
+	}
+	// Inserted only when the stock of the product not held by the active reservations covers the kg
+	db.execs["CreateStockReservation"] = func(args []driver.NamedValue) (driver.Result, error) {
+		i := slices.IndexFunc(products, func(p testProduct) bool { return p.Codigo == args[7].Value })
+		if i < 0 {
+			return fakeResult{}, nil
+		}
+		available := products[i].ExistenciaKg
+		for _, row := range db.table("ai_stock_reservations").selectWhere(1, args[7].Value) {
+			if active(row, args[8].Value) {
+				available -= row[3].(float64)
+			}
+		}
+		if roundCents(available) < args[9].Value.(float64) {
+			return fakeResult{}, nil
+		}
+		row := []driver.NamedValue{{Value: products[i].Codigo}, {Value: products[i].Descripcion}}
+		row = append(row, args[:7]...)
+		return db.table("ai_stock_reservations").insert(append(row, driver.NamedValue{Value: nil})), nil
+	}
+	db.execs["ExtendStockReservation"] = func(args []driver.NamedValue) (driver.Result, error) {
+		var affected int64
+		for _, row := range db.table("ai_stock_reservations").selectWhere(0, args[1].Value) {
+			if active(row, args[2].Value) {
+				row[9] = args[0].Value
+				affected++
+			}
+		}
+		return fakeResult{rowsAffected: affected}, nil
+	}
+	db.execs["ReleaseStockReservation"] = func(args []driver.NamedValue) (driver.Result, error) {
+		var affected int64
+		for _, row := range db.table("ai_stock_reservations").selectWhere(0, args[1].Value) {
+			if row[10] == nil {
+				row[10] = args[0].Value
+				affected++
+			}
+		}
+		return fakeResult{rowsAffected: affected}, nil
+	}
+	db.handlers["GetStockReservation"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return reservationColumns, db.table("ai_stock_reservations").selectWhere(0, args[0].Value), nil
+	}
+	db.handlers["ListActiveStockReservations"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		var rows [][]driver.Value
+		for _, row := range db.table("ai_stock_reservations").selectWhere(6, args[0].Value) {
+			if active(row, args[1].Value) {
+				rows = append(rows, row)
+			}
+		}
+		return reservationColumns, rows, nil
+	}
+	db.handlers["GetActiveStockHolds"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		var rows [][]driver.Value
+		for _, code := range args[1:] {
+			var held float64
+			for _, row := range db.table("ai_stock_reservations").selectWhere(1, code.Value) {
+				if active(row, args[0].Value) {
+					held += row[3].(float64)
+				}
+			}
+			if held > 0 {
+				rows = append(rows, []driver.Value{code.Value, held})
+			}
+		}
+		return []string{"codigo", "reservado_kg"}, rows, nil
+	}
+
+	// The approvals joined with ai_quotes, for the cliente and the total of the quote
+	quoteOf := func(approval []driver.Value) []driver.Value {
+		return db.table("ai_quotes").selectWhere(0, approval[1])[0]
+	}
+	db.handlers["ListQuoteApprovals"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		var rows [][]driver.Value
+		for _, row := range db.table("ai_quote_approvals").selectWhere(4, args[0].Value) {
+			quote := quoteOf(row)
+			rows = append(rows, append(slices.Clone(row[:9]), quote[1], quote[7]))
+		}
+		return append(slices.Clone(approvalColumns[:9]), "cliente", "total"), rows, nil
+	}
+	db.handlers["ListUnnotifiedApprovals"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		var rows [][]driver.Value
+		for _, row := range db.table("ai_quote_approvals").selectWhere(2, args[0].Value) {
+			if row[4] != "pendiente" && row[9] == false {
+				rows = append(rows, []driver.Value{row[0], row[1], row[4], row[5], row[6], quoteOf(row)[1]})
+			}
+		}
+		return []string{"id", "quote_id", "estado", "revisor", "comentario", "cliente"}, rows, nil
+	}
+	db.handlers["GetQuote"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return quoteColumns, db.table("ai_quotes").selectWhere(0, args[0].Value), nil
+	}
+	db.handlers["GetQuoteLines"] = func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+		return lineColumns, db.table("ai_quote_lines").selectWhere(1, args[0].Value), nil
+	}
+}
+
+func contains(value string, arg driver.NamedValue) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(fmt.Sprint(arg.Value)))
+}
+
+func codeRows(products []testProduct, match func(testProduct) bool) ([]string, [][]driver.Value, error) {
+	var rows [][]driver.Value
+	for _, p := range products {
+		if match(p) {
+			rows = append(rows, []driver.Value{p.Codigo, p.Descripcion})
+		}
+	}
+	return []string{"codigo", "descripcion"}, rows, nil
+}
+
+// customerSales returns the sales of the customer since the date in args, newest first
+func customerSales(sales []testSale, args []driver.NamedValue) []testSale {
+	var matched []testSale
+	for _, s := range sales {
+		if s.Cliente == args[0].Value && s.Fecha >= args[1].Value.(string) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+func catalogHandlers(products []testProduct, sales []testSale, lots []testLot) map[string]queryHandler {
+	return map[string]queryHandler{
+		"GetAllProductCodes": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			return codeRows(products, func(p testProduct) bool { return true })
+		},
+		"GetProductCodesByBrand": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			return codeRows(products, func(p testProduct) bool { return contains(p.Marca, args[0]) })
+		},
+		"GetProductCodesBySearchTerm": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			return codeRows(products, func(p testProduct) bool {
+				return contains(p.Linea, args[0]) || contains(p.Sublinea, args[1]) || contains(p.Descripcion, args[2])
+			})
+		},
+		"GetProductCodesByCategory": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, p := range products {
+				if contains(p.Linea, args[0]) && contains(p.Sublinea, args[1]) {
+					rows = append(rows, []driver.Value{p.Codigo, p.Descripcion, p.Sublinea})
+				}
+			}
+			return []string{"codigo", "descripcion", "vsublin"}, rows, nil
+		},
+		"GetProductsInfoByCode": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var codes []string
+			for _, arg := range args {
+				codes = append(codes, fmt.Sprint(arg.Value))
+			}
+			var rows [][]driver.Value
+			for _, p := range products {
+				if !slices.Contains(codes, p.Codigo) {
+					continue
+				}
+				rows = append(rows, []driver.Value{
+					p.Codigo, p.Descripcion, p.Marca, p.ExistenciaKg, p.PesoPromedioCajaKg, p.PiezasPorCaja,
+					p.PesoPromedioCajaKg / float64(p.PiezasPorCaja), p.PrecioDetalle, p.EscalaDetalle,
+					p.PrecioMedioMayoreo, p.EscalaMedioMayoreo, p.PrecioMayoreo,
+				})
+			}
+			return []string{
+				"codigo", "descripcion", "marca", "existencia_kg", "peso_promedio_caja_kg", "piezas_por_caja",
+				"peso_promedio_pieza_kg", "precio_detalle", "escala_detalle", "precio_medio_mayoreo",
+				"escala_medio_mayoreo", "precio_mayoreo",
+			}, rows, nil
+		},
+		"GetProductStockLevels": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, p := range products {
+				if slices.ContainsFunc(args, func(arg driver.NamedValue) bool { return arg.Value == p.Codigo }) {
+					rows = append(rows, []driver.Value{p.Codigo, p.Descripcion, p.ExistenciaKg, p.MinimoKg, p.MaximoKg, p.DisponibleKg})
+				}
+			}
+			return []string{"codigo", "descripcion", "existencia_kg", "minimo_kg", "maximo_kg", "disponible_kg"}, rows, nil
+		},
+		"GetStockAlerts": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			flagged := slices.DeleteFunc(slices.Clone(products), func(p testProduct) bool {
+				return !(p.MinimoKg > 0 && p.ExistenciaKg < p.MinimoKg) && !(p.MaximoKg > 0 && p.ExistenciaKg > p.MaximoKg)
+			})
+			slices.SortFunc(flagged, func(a, b testProduct) int {
+				return cmp.Or(cmp.Compare(a.Linea, b.Linea), cmp.Compare(a.Descripcion, b.Descripcion))
+			})
+			var rows [][]driver.Value
+			for _, p := range flagged {
+				rows = append(rows, []driver.Value{p.Codigo, p.Descripcion, p.Linea, p.Marca, p.ExistenciaKg, p.MinimoKg, p.MaximoKg, p.DisponibleKg})
+			}
+			return []string{"codigo", "descripcion", "linea", "marca", "existencia_kg", "minimo_kg", "maximo_kg", "disponible_kg"}, rows, nil
+		},
+		"GetProductInboundLots": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, l := range lots {
+				if l.Fecha >= args[0].Value.(string) && slices.ContainsFunc(args[1:], func(arg driver.NamedValue) bool { return arg.Value == l.Codigo }) {
+					rows = append(rows, []driver.Value{l.Codigo, l.Folio, l.Fecha, l.Kg, l.expiry()})
+				}
+			}
+			return []string{"codigo", "folio", "fecha", "kg", "caducidad"}, rows, nil
+		},
+		"GetProductsWithExpiringLots": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			expiring := slices.DeleteFunc(slices.Clone(products), func(p testProduct) bool {
+				return p.ExistenciaKg <= 0 || !slices.ContainsFunc(lots, func(l testLot) bool {
+					return l.Codigo == p.Codigo && l.Fecha >= args[0].Value.(string) && l.Caducidad != "" && !l.expiry().After(args[1].Value.(time.Time))
+				})
+			})
+			slices.SortFunc(expiring, func(a, b testProduct) int {
+				return cmp.Or(cmp.Compare(a.Linea, b.Linea), cmp.Compare(a.Descripcion, b.Descripcion))
+			})
+			var rows [][]driver.Value
+			for _, p := range expiring {
+				rows = append(rows, []driver.Value{p.Codigo, p.Descripcion, p.Linea, p.ExistenciaKg, p.PrecioDetalle, p.PrecioMedioMayoreo, p.PrecioMayoreo})
+			}
+			return []string{"codigo", "descripcion", "linea", "existencia_kg", "precio_detalle", "precio_medio_mayoreo", "precio_mayoreo"}, rows, nil
+		},
+		"GetQuoteProducts": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, p := range products {
+				if !slices.ContainsFunc(args, func(arg driver.NamedValue) bool { return arg.Value == p.Codigo }) {
+					continue
+				}
+				rows = append(rows, []driver.Value{
+					p.Codigo, p.Descripcion, p.PesoPromedioCajaKg, p.PiezasPorCaja, p.TasaIva, p.PrecioDetalle,
+					p.EscalaDetalle, p.PrecioMedioMayoreo, p.EscalaMedioMayoreo, p.PrecioMayoreo,
+				})
+			}
+			return []string{
+				"codigo", "descripcion", "peso_promedio_caja_kg", "piezas_por_caja", "tasa_iva", "precio_detalle",
+				"escala_detalle", "precio_medio_mayoreo", "escala_medio_mayoreo", "precio_mayoreo",
+			}, rows, nil
+		},
+		"SearchCustomers": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			index := map[string]int{}
+			for _, s := range sales {
+				if s.Cliente != args[0].Value && !contains(s.RazonSocial, args[1]) {
+					continue
+				}
+				i, ok := index[s.Cliente]
+				if !ok {
+					i = len(rows)
+					index[s.Cliente] = i
+					rows = append(rows, []driver.Value{s.Cliente, s.RazonSocial, s.Fecha, int64(0)})
+				}
+				// Every test sale line counts as its own folio
+				rows[i][3] = rows[i][3].(int64) + 1
+			}
+			return []string{"numero", "razon_social", "ultima_compra", "compras"}, rows, nil
+		},
+		"GetCustomerSales": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, s := range customerSales(sales, args) {
+				row := []driver.Value{s.Folio, s.Fecha, s.RazonSocial, s.Codigo, s.Descripcion, s.Cantidad, s.Importe, s.NivelPrecio, s.PrecioLista}
+				for _, d := range s.Descuentos {
+					row = append(row, d)
+				}
+				rows = append(rows, row)
+			}
+			return []string{
+				"folio", "fecha", "razon_social", "codigo", "descripcion", "cantidad", "importe", "nivel_precio", "precio_lista",
+				"vdto001", "vdto002", "vdto003", "vdto004", "vdto005", "vdto006", "vdto007", "vdto008", "vdto009", "vdto010",
+			}, rows, nil
+		},
+		"GetCustomerOrders": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			index := map[int32]int{}
+			for _, s := range customerSales(sales, args) {
+				i, ok := index[s.Folio]
+				if !ok {
+					i = len(rows)
+					index[s.Folio] = i
+					rows = append(rows, []driver.Value{s.Folio, s.RazonSocial, s.Fecha, int64(0), 0.0, 0.0})
+				}
+				rows[i][3] = rows[i][3].(int64) + 1
+				rows[i][4] = rows[i][4].(float64) + s.Cantidad
+				rows[i][5] = rows[i][5].(float64) + s.Importe
+			}
+			return []string{"folio", "razon_social", "fecha", "renglones", "kg", "importe"}, rows, nil
+		},
+		"GetCustomerProductHistory": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			index := map[string]int{}
+			for _, s := range customerSales(sales, args) {
+				i, ok := index[s.Codigo]
+				if !ok {
+					i = len(rows)
+					index[s.Codigo] = i
+					rows = append(rows, []driver.Value{s.Codigo, s.Descripcion, int64(0), 0.0, 0.0, s.Fecha, s.Fecha})
+				}
+				rows[i][2] = rows[i][2].(int64) + 1
+				rows[i][3] = rows[i][3].(float64) + s.Cantidad
+				rows[i][4] = rows[i][4].(float64) + s.Importe
+				rows[i][5] = s.Fecha
+			}
+			slices.SortStableFunc(rows, func(a, b []driver.Value) int {
+				if c := cmp.Compare(b[2].(int64), a[2].(int64)); c != 0 {
+					return c
+				}
+				return cmp.Compare(b[3].(float64), a[3].(float64))
+			})
+			return []string{"codigo", "descripcion", "pedidos", "kg", "importe", "primera_compra", "ultima_compra"}, rows, nil
+		},
+		"GetProductCosts": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, p := range products {
+				if slices.ContainsFunc(args, func(arg driver.NamedValue) bool { return arg.Value == p.Codigo }) {
+					rows = append(rows, []driver.Value{
+						p.Codigo, p.Descripcion, p.CostoUltimo, p.CostoAnterior, p.PrecioDetalle, 1.0,
+						p.PrecioDetalle, p.PrecioMedioMayoreo, p.PrecioMayoreo,
+					})
+				}
+			}
+			return []string{
+				"codigo", "descripcion", "costo_ultimo", "costo_anterior", "precio_actual", "factor_referencia",
+				"precio_detalle", "precio_medio_mayoreo", "precio_mayoreo",
+			}, rows, nil
+		},
+		"GetProductPolicyData": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, p := range products {
+				if slices.ContainsFunc(args, func(arg driver.NamedValue) bool { return arg.Value == p.Codigo }) {
+					rows = append(rows, []driver.Value{p.Codigo, p.Descripcion, p.Linea, p.Marca, p.CostoUltimo, p.PrecioMayoreo})
+				}
+			}
+			return []string{"codigo", "descripcion", "linea", "marca", "costo_ultimo", "precio_mayoreo"}, rows, nil
+		},
+		"GetProductPriceLevels": func(args []driver.NamedValue) ([]string, [][]driver.Value, error) {
+			var rows [][]driver.Value
+			for _, p := range products {
+				if slices.ContainsFunc(args, func(arg driver.NamedValue) bool { return arg.Value == p.Codigo }) {
+					rows = append(rows, []driver.Value{p.Codigo, p.Descripcion, p.PrecioDetalle, p.PrecioMedioMayoreo, p.PrecioMayoreo, 0.0, 0.0, 0.0})
+				}
+			}
+			return []string{"codigo", "descripcion", "fac1", "fac2", "fac3", "fac4", "fac5", "fac6"}, rows, nil
+		},
+	}
+}
+
+// driver.Connector and driver.Driver
+
+func (db *fakeDB) Connect(ctx context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+func (db *fakeDB) Driver() driver.Driver                            { return db }
+func (db *fakeDB) Open(name string) (driver.Conn, error)            { return &fakeConn{db: db}, nil }
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: prepared statements are not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("fakedb: transactions are not supported")
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	name, ok := queryName(query)
+	if !ok {
+		return nil, fmt.Errorf("fakedb: statement without sqlc name: %q", query)
+	}
+	handler, ok := c.db.execs[name]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: no handler for statement %s", name)
+	}
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	return handler(args)
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	name, ok := queryName(query)
+	if !ok {
+		return nil, fmt.Errorf("fakedb: query without sqlc name: %q", query)
+	}
+	handler, ok := c.db.handlers[name]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: no handler for query %s", name)
+	}
+	c.db.mu.Lock()
+	columns, rows, err := handler(args)
+	c.db.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: columns, rows: rows}, nil
+}
+
+// queryName extracts X from the "-- name: X :many" header of a sqlc query
+func queryName(query string) (string, bool) {
+	header, ok := strings.CutPrefix(strings.TrimSpace(query), "-- name: ")
+	if !ok {
+		return "", false
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return "", false
+	}
+	return fields[0], true
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
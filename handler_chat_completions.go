@@ -2,163 +2,296 @@ package main
 
 import (
 	"context"
-	"copo-ai-agent/internal/database"
-	"copo-ai-agent/internal/utils"
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
-	"google.golang.org/genai"
 )
 
-func chatCompletionsHandler(w http.ResponseWriter, r *http.Request) {
+func (app *App) chatCompletionsHandler(w http.ResponseWriter, r *http.Request) {
 	// Check for correct method POST
 	if r.Method != http.MethodPost {
 		log.Printf("method not allowed: %v...\n", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method_not_allowed", "Method not allowed")
 		return
 	}
 
+	identity, _ := identityFromContext(r.Context())
+
 	// Decode request
 	var req OpenAIRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Println("invalid request body...")
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid_body", "Invalid request body")
 		return
 	}
 
-	// Extract the latest user message
-	var userQuery string
-	if len(req.Messages) > 0 {
-		userQuery = req.Messages[len(req.Messages)-1].Content
-	} else {
-		log.Println("no messages in request...")
-		http.Error(w, "No messages in request", http.StatusBadRequest)
+	// The model field selects the agent profile
+	profile, ok := app.profiles.resolve(req.Model)
+	if !ok {
+		log.Printf("unknown model: %s...\n", req.Model)
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "model_not_found",
+			fmt.Sprintf("The model %q does not exist", req.Model))
+		return
+	}
+
+	// Convert the message list into the conversation history
+	conv, err := buildConversation(req.Messages, app.config.HistoryTokenBudget)
+	if err != nil {
+		log.Printf("invalid conversation: %v\n", err)
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid_messages", "Invalid conversation: "+err.Error())
+		return
+	}
+
+	// The whole request, including every model call and tool, must finish within the request budget.
+	// The context is also cancelled when the client disconnects.
+	ctx, cancel := context.WithTimeout(r.Context(), app.config.RequestTimeout)
+	defer cancel()
+
+	completionID := "chatcmpl-custom-" + uuid.New().String()
+	created := time.Now().Unix()
+
+	if req.Stream {
+		includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+		app.streamUserQuery(ctx, w, completionID, created, profile, identity, conv, includeUsage)
 		return
 	}
 
 	// Process suer query
-	geminiResponseContent, err := processUserQuery(userQuery)
+	result, err := app.processUserQuery(ctx, profile, identity, conv, nil)
+	app.usage.record(identity.User, profile.Model, result.Usage)
+	app.recorder.record(identity, profile, conv, result, err)
 	if err != nil {
+		if errors.Is(r.Context().Err(), context.Canceled) {
+			log.Println("client closed the request...")
+			return
+		}
 		log.Printf("failed to process user query: %v\n", err)
-		http.Error(w, "Failed to get response from gemini", http.StatusInternalServerError)
+		status, openAIErr := processingError(err)
+		writeOpenAIError(w, status, openAIErr.Type, openAIErr.Code, openAIErr.Message)
 		return
 	}
 
+	// Decisions of the managers on the quotes of the rep go in a paragraph before the answer
+	notice, notified := app.approvalNotices(ctx, identity.User)
+
 	// Generate OpenAIResponse struct
 	openAIResp := OpenAIResponse{
-		ID:      "chatcmpl-custom-" + uuid.New().String(),
+		ID:      completionID,
 		Object:  "chat.completion",
-		Created: 0,
-		Model:   GeminiModel,
+		Created: created,
+		Model:   profile.ID,
 		Choices: []OpenAIChoice{
 			{
 				Index: 0,
 				Message: OpenAIMessage{
 					Role:    "assistant",
-					Content: geminiResponseContent,
+					Content: notice + profile.Formatter.format(result.Text),
 				},
 			},
 		},
-		Usage: OpenAIUsage{
-			PromptTokens:     0,
-			CompletionTokens: 0,
-			TotalTokens:      0,
-		},
+		Usage: result.Usage.toOpenAI(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(openAIResp)
+	if err := json.NewEncoder(w).Encode(openAIResp); err != nil {
+		log.Printf("failed to write response: %v\n", err)
+		return
+	}
+	app.markApprovalsNotified(ctx, notified)
 }
 
-func processUserQuery(userQuery string) (string, error) {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{APIKey: GeminiKey})
+// streamUserQuery answers a stream: true request with chat.completion.chunk events.
+// The role and the formatter header are sent right away so the UI shows activity while the tool calls run.
+func (app *App) streamUserQuery(ctx context.Context, w http.ResponseWriter, completionID string, created int64, profile *AgentProfile, identity Identity, conv conversation, includeUsage bool) {
+	stream, err := newSSEWriter(w, completionID, profile.ID, created)
 	if err != nil {
-		return "", fmt.Errorf("failed to create client: %w", err)
+		log.Printf("failed to start stream: %v\n", err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "streaming_unsupported", "Streaming not supported")
+		return
 	}
 
-	chat, err := client.Chats.Create(
-		ctx,
-		GeminiModel,
-		&genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{
-				Parts: []*genai.Part{{Text: getSystemPrompt()}},
-			},
-			Tools: []*genai.Tool{
-				{
-					FunctionDeclarations: ToolFunctions.getDeclarationsList(),
-				},
-			},
-		},
-		nil,
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to create chat: %w", err)
+	// Decisions of the managers on the quotes of the rep go in a chunk of their own, before the
+	// header of the answer
+	notice, notified := app.approvalNotices(ctx, identity.User)
+	if notice != "" {
+		stream.writeChunk(OpenAIDelta{Role: "assistant", Content: notice}, nil)
+		stream.writeText(profile.Formatter.prefix())
+	} else {
+		stream.writeChunk(OpenAIDelta{Role: "assistant", Content: profile.Formatter.prefix()}, nil)
 	}
 
-	resp, err := chat.SendMessage(ctx, genai.Part{Text: userQuery})
+	result, err := app.processUserQuery(ctx, profile, identity, conv, stream)
+	app.usage.record(identity.User, profile.Model, result.Usage)
+	app.recorder.record(identity, profile, conv, result, err)
 	if err != nil {
-		return "", fmt.Errorf("failed to send message %w", err)
+		if errors.Is(ctx.Err(), context.Canceled) {
+			log.Println("client closed the stream...")
+			return
+		}
+		log.Printf("failed to process user query: %v\n", err)
+		_, openAIErr := processingError(err)
+		stream.writeError(openAIErr)
+		return
 	}
 
-	db, err := sql.Open("mysql", utils.GetConnString())
-	if err != nil {
-		log.Printf("failed to open db (%s): %v", utils.GetConnString(), err)
-		return "", fmt.Errorf("ocurrió un error al obtener la lista de productos")
-	}
-	defer db.Close()
-	queries := database.New(db)
-
-	for {
-		log.Printf("total usage: %v tokens\n", resp.UsageMetadata.TotalTokenCount)
-
-		if len(resp.FunctionCalls()) > 0 {
-			// log.Println("found FunctionCall...")
-			fc := resp.FunctionCalls()[0]
-
-			// log.Printf("executing %s()...", fc.Name)
-			var result string
-
-			functionTool := ToolFunctions.getToolByName(fc.Name)
-			result = functionTool.Function(queries, fc.Args)
-			// log.Println("sending function result back to Gemini...")
-			resp, err = chat.SendMessage(
-				ctx,
-				genai.Part{
-					FunctionResponse: &genai.FunctionResponse{
-						Name: "obtenerListaDeProductos",
-						Response: map[string]any{
-							"result": result,
-						},
-					},
-				},
-			)
-			if err != nil {
-				log.Printf("failed to send function response: %v", err)
-				return "", fmt.Errorf("failed to send function response %w", err)
+	var usage *OpenAIUsage
+	if includeUsage {
+		openAIUsage := result.Usage.toOpenAI()
+		usage = &openAIUsage
+	}
+
+	stream.writeText(profile.Formatter.suffix())
+	stream.finish("stop", usage)
+	if err := stream.failed(); err != nil {
+		log.Printf("failed to write stream: %v\n", err)
+		return
+	}
+	app.markApprovalsNotified(ctx, notified)
+}
+
+// queryResult is the final text of a request, the tools it called, the tokens used across all rounds
+// and the figures the answer check corrected or flagged
+type queryResult struct {
+	Text      string
+	ToolCalls []toolExchange
+	Usage     tokenUsage
+	// Figures of the answer that did not match the tool results
+	Issues []answerIssue
+}
+
+// toolExchange is a tool call made during a request together with the result sent back to the model
+type toolExchange struct {
+	Round    int            `json:"round"`
+	Name     string         `json:"name"`
+	Args     map[string]any `json:"args"`
+	Response map[string]any `json:"response"`
+}
+
+// processUserQuery runs the tool loop with the provider, model, prompt and tools of the profile.
+// When stream is not nil, every text delta is also forwarded to the client as it arrives.
+// The usage of the rounds already completed is returned even when an error occurs.
+func (app *App) processUserQuery(ctx context.Context, profile *AgentProfile, identity Identity, conv conversation, stream *sseWriter) (queryResult, error) {
+	var result queryResult
+	log.Printf("processing query from %s (%s) with profile %s...\n", identity.User, identity.Branch, profile.ID)
+
+	systemPrompt := profile.SystemPrompt
+	if conv.SystemInstructions != "" {
+		systemPrompt += "\nInstrucciones adicionales:\n" + conv.SystemInstructions
+	}
+
+	// A profile with manager only tools still hides them from the sales reps
+	tools := profile.Tools.forRole(identity.Role)
+	req := LLMRequest{
+		Model:        profile.Model,
+		SystemPrompt: systemPrompt,
+		Messages:     append(conv.History, LLMMessage{Role: llmRoleUser, Text: conv.UserQuery}),
+		Tools:        tools.getDeclarationsList(),
+		Temperature:  profile.Temperature,
+	}
+
+	var onText func(string)
+	if stream != nil {
+		onText = stream.writeText
+	}
+
+	reprompted := false
+	for round := 0; ; round++ {
+		resp, err := app.generate(ctx, profile.Provider, req, onText)
+		result.Usage.addUsage(resp.Usage)
+		if err != nil {
+			return result, fmt.Errorf("failed to generate response: %w", err)
+		}
+
+		if len(resp.ToolCalls) == 0 {
+			// Every price, scale and stock figure of the answer must come from the tools
+			check := checkAnswer(resp.Text, result.ToolCalls)
+			if len(check.Unknown) > 0 && !reprompted && stream == nil && round < maxToolRounds {
+				log.Printf("answer quotes unverified codes %v, asking the model again...\n", check.Unknown)
+				reprompted = true
+				req.Messages = append(req.Messages,
+					LLMMessage{Role: llmRoleAssistant, Text: resp.Text},
+					LLMMessage{Role: llmRoleUser, Text: repromptMessage(check)},
+				)
+				continue
 			}
-		} else {
-			// log.Println("no FunctionCall found...")
+			result.Text = app.finishAnswer(check, resp.Text, stream)
+			result.Issues = check.issues()
 			break
 		}
+		if round >= maxToolRounds {
+			return result, fmt.Errorf("exceeded maximum of %d function-call rounds", maxToolRounds)
+		}
+
+		if stream != nil {
+			for _, call := range resp.ToolCalls {
+				stream.writeComment("ejecutando " + call.Name)
+			}
+		}
+
+		results := app.executeToolCalls(ctx, tools, resp.ToolCalls)
+		for i, call := range resp.ToolCalls {
+			result.ToolCalls = append(result.ToolCalls, toolExchange{
+				Round:    round,
+				Name:     call.Name,
+				Args:     call.Args,
+				Response: results[i].Response,
+			})
+		}
+
+		// The products chosen with mostrarProductos are rendered from the database rows and end the request
+		cards, ok, err := productCardsAnswer(profile, resp.ToolCalls, results)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			if strings.TrimSpace(resp.Text) != "" {
+				cards = "\n\n" + cards
+			}
+			if stream != nil {
+				stream.writeText(cards)
+			}
+			result.Text = resp.Text + cards
+			break
+		}
+		req.Messages = append(req.Messages,
+			LLMMessage{Role: llmRoleAssistant, Text: resp.Text, ToolCalls: resp.ToolCalls},
+			LLMMessage{Role: llmRoleTool, ToolResults: results},
+		)
 	}
 
-	return formatResponse(resp.Text()), nil
+	log.Printf("total usage: %v tokens\n", result.Usage.TotalTokens)
+	return result, nil
 }
 
-func formatResponse(response string) string {
-	header := `*¡Hola! 😊 Gracias por tu interés en nuestros productos!*
+// finishAnswer returns the final text of the answer after the check. Mismatched figures are corrected
+// in place, except when the text was already streamed, in which case the corrections are sent as a notice.
+// Codes that could not be verified are always flagged with a notice.
+func (app *App) finishAnswer(check answerCheck, text string, stream *sseWriter) string {
+	if len(check.Corrected) > 0 {
+		log.Printf("answer quoted %d figures that do not match the tools, correcting...\n", len(check.Corrected))
+	}
+	if len(check.Unknown) > 0 {
+		log.Printf("answer quotes unverified codes %v, flagging...\n", check.Unknown)
+	}
 
-🚚 Hacemos entregas en Tula, Tepeji, Chapantongo, Jilotepec, Huehuetoca, Ixmiquilpan, Mixquiahuala y alrededores.`
-	foot := `📍 También puedes visitarnos aquí: https://maps.app.goo.gl/QDv4HnqqJhqQ24BP8?g_st=ac
-📲 Mándanos mensaje por WhatsApp: https://wa.me/527731819900
-🐔 *COPOCAR* agradece tu preferencia!🙏`
+	if stream != nil {
+		notice := answerNotice(check, false)
+		stream.writeText(notice)
+		return text + notice
+	}
+	return check.Text + answerNotice(check, true)
+}
 
-	return fmt.Sprintf("%s\n\n\n%s\n\n\n%s", header, response, foot)
+// generate calls the provider limited by the LLM timeout on top of the request deadline
+func (app *App) generate(ctx context.Context, provider LLMProvider, req LLMRequest, onText func(string)) (LLMResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, app.config.LLMTimeout)
+	defer cancel()
+	return provider.Generate(ctx, req, onText)
 }
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"copo-ai-agent/internal/database"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+const (
+	testAPIKey        = "test-key"
+	testOtherAPIKey   = "other-key"
+	testManagerAPIKey = "manager-key"
+
+	// Profile with every tool, manager only ones included
+	testManagerProfile = "COPO-AI-gerencia"
+)
+
+// testNow is the clock of the tests, so saved quotes have the same dates on every run
+var testNow = time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+
+// testPricingPolicy keeps the mayoreo prices of the catalog within policy
+var testPricingPolicy = pricingPolicy{MinMarginPct: 5, Marcas: map[string]float64{"FUD": 12}}
+
+// fixClock sets timeNow for the duration of the test
+func fixClock(t *testing.T, now time.Time) {
+	t.Helper()
+	previous := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = previous })
+}
+
+// newTestServer runs the agent against the fake provider and the in-memory catalog
+func newTestServer(t *testing.T, provider LLMProvider) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(newTestApp(t, provider).routes())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newTestApp builds an App on the in-memory catalog with the fake provider
+func newTestApp(t *testing.T, provider LLMProvider) *App {
+	t.Helper()
+
+	db := newFakeDB(testCatalog)
+	t.Cleanup(func() { db.Close() })
+
+	cfg := Config{
+		GeminiModel:        "fake-model",
+		DefaultProfile:     "COPO-AI",
+		HistoryTokenBudget: defaultHistoryTokenBudget,
+		RequestTimeout:     10 * time.Second,
+		LLMTimeout:         5 * time.Second,
+		DBQueryTimeout:     5 * time.Second,
+		QuoteValidity:      48 * time.Hour,
+		ReservationTTL:     24 * time.Hour,
+		ReservationMaxTTL:  72 * time.Hour,
+		PublicBaseURL:      "https://agente.example.com",
+		QuoteLinkKey:       []byte("test-quote-link-key"),
+	}
+	tools := getCompletionTools(cfg, testPricingPolicy)
+	providers := map[string]LLMProvider{"gemini": provider}
+	defaults := defaultProfiles(cfg, providers, tools)
+	manager := *defaults[0]
+	manager.ID = testManagerProfile
+	manager.Tools = tools
+	profiles, err := newProfileRegistry(cfg.DefaultProfile, append(defaults, &manager)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err := newUsageLedger("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &App{
+		config:    cfg,
+		db:        db,
+		queries:   database.New(queryTimeoutDB{db: db, timeout: cfg.DBQueryTimeout}),
+		providers: providers,
+		tools:     tools,
+		profiles:  profiles,
+		toolStats: newToolStatsRegistry(),
+		usage:     usage,
+		keys: &fileKeyStore{keys: map[string]Identity{
+			hashAPIKey(testAPIKey):        {User: "mostrador", Name: "Mostrador", Branch: "Tula", Role: roleSales},
+			hashAPIKey(testOtherAPIKey):   {User: "ruta2", Name: "Ruta 2", Branch: "Tula", Role: roleSales},
+			hashAPIKey(testManagerAPIKey): {User: "gerencia", Name: "Gerencia", Branch: "Tula", Role: roleManager},
+		}},
+	}
+	return app
+}
+
+func postChat(t *testing.T, srv *httptest.Server, apiKey string, req OpenAIRequest) (*http.Response, []byte) {
+	t.Helper()
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+
+	resp, err := srv.Client().Do(httpReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var respBody bytes.Buffer
+	if _, err := respBody.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	return resp, respBody.Bytes()
+}
+
+// assertGolden compares got with testdata/golden/name.golden, rewriting it with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match the golden file\n--- got\n%s\n--- want\n%s", name, got, want)
+	}
+}
+
+func userRequest(content string) OpenAIRequest {
+	return OpenAIRequest{
+		Model:    "COPO-AI",
+		Messages: []OpenAIMessage{{Role: "user", Content: content}},
+	}
+}
+
+func TestChatCompletionsTools(t *testing.T) {
+	fixClock(t, testNow)
+
+	tests := []struct {
+		golden string
+		tool   string
+		args   map[string]any
+	}{
+		{"tool_obtenerListaProductos", "obtenerListaProductos", map[string]any{}},
+		{"tool_obtenerInformacionPorBusqueda", "obtenerInformacionPorBusqueda", map[string]any{"searchTerm": "pollo"}},
+		{"tool_obtenerInformacionPorMarca", "obtenerInformacionPorMarca", map[string]any{"brand": "fud"}},
+		{"tool_obtenerInformacionPorLineaSublinea", "obtenerInformacionPorLineaSublinea", map[string]any{"linea": "pollo", "sublinea": ""}},
+		{"tool_obtenerInformacionPorCodigo", "obtenerInformacionPorCodigo", map[string]any{"productCodes": []any{"101", "205"}}},
+		{"tool_mostrarProductos", "mostrarProductos", map[string]any{"productCodes": []any{"205", "101"}, "mensaje": "Estos son los productos que encontré:"}},
+		{"tool_cotizar", "cotizar", map[string]any{"productos": []any{
+			map[string]any{"codigo": "101", "cantidad": 3.0, "unidad": "cajas"},
+			map[string]any{"codigo": "205", "cantidad": 50.0, "unidad": "piezas"},
+			map[string]any{"codigo": "102", "cantidad": 120.0, "unidad": "kg"},
+		}}},
+		{"tool_guardarCotizacion", "guardarCotizacion", map[string]any{
+			"cliente": "Carnicería Don Pepe",
+			"notas":   "Entrega el martes",
+			"productos": []any{
+				map[string]any{"codigo": "101", "cantidad": 3.0, "unidad": "cajas"},
+				map[string]any{"codigo": "205", "cantidad": 50.0, "unidad": "piezas"},
+			},
+		}},
+		{"tool_buscarCliente", "buscarCliente", map[string]any{"busqueda": "lopez"}},
+		{"tool_obtenerCondicionesCliente", "obtenerCondicionesCliente", map[string]any{"numeroCliente": "C-118"}},
+		{"tool_obtenerPreciosCliente", "obtenerPreciosCliente", map[string]any{"numeroCliente": "C-118", "productCodes": []any{"101", "102"}}},
+		{"tool_obtenerHistorialCliente", "obtenerHistorialCliente", map[string]any{"numeroCliente": "C-118"}},
+		{"tool_sugerirPedido", "sugerirPedido", map[string]any{"numeroCliente": "C-118"}},
+		{"tool_obtenerCostoMargen", "obtenerCostoMargen", map[string]any{
+			"productCodes":      []any{"101", "102"},
+			"preciosPropuestos": []any{map[string]any{"codigo": "101", "precio": 85.0}},
+		}},
+		{"tool_apartarExistencia", "apartarExistencia", map[string]any{
+			"codigo": "101", "kg": 30.0, "cliente": "Carnicería Don Pepe", "numeroCliente": "C-118",
+		}},
+		{"tool_extenderReserva", "extenderReserva", map[string]any{"reserva": 1.0, "horas": 12.0}},
+		{"tool_liberarReserva", "liberarReserva", map[string]any{"reserva": 1.0}},
+		{"tool_obtenerReservas", "obtenerReservas", map[string]any{}},
+		{"tool_obtenerAlertasInventario", "obtenerAlertasInventario", map[string]any{}},
+		{"tool_obtenerExistenciaPorCaducidad", "obtenerExistenciaPorCaducidad", map[string]any{"productCodes": []any{"101", "102", "205"}}},
+		{"tool_sugerirRemate", "sugerirRemate", map[string]any{}},
+		{"tool_invalid_argument", "obtenerInformacionPorMarca", map[string]any{"brand": 7}},
+		{"tool_unknown", "borrarProductos", map[string]any{}},
+	}
+
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		covered[tt.tool] = true
+	}
+	managerOnly := map[string]bool{}
+	for _, tool := range getCompletionTools(Config{}, pricingPolicy{}).Tools {
+		if !covered[tool.Name] {
+			t.Errorf("tool %s has no golden test", tool.Name)
+		}
+		managerOnly[tool.Name] = tool.ManagerOnly
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			provider := newFakeProvider(
+				scriptedTurn{ToolCalls: []ToolCall{{ID: "call-1", Name: tt.tool, Args: tt.args}}},
+				scriptedTurn{Text: "Aquí está la información solicitada."},
+			)
+			srv := newTestServer(t, provider)
+
+			apiKey, req := testAPIKey, userRequest("¿qué productos tienen?")
+			if managerOnly[tt.tool] {
+				apiKey, req.Model = testManagerAPIKey, testManagerProfile
+			}
+			resp, body := postChat(t, srv, apiKey, req)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+			}
+
+			var completion OpenAIResponse
+			if err := json.Unmarshal(body, &completion); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.MarshalIndent(map[string]any{
+				"tool_results": provider.toolResults(),
+				"content":      completion.Choices[0].Message.Content,
+			}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, tt.golden, got)
+		})
+	}
+}
+
+func TestFormatResponse(t *testing.T) {
+	for name, formatter := range responseFormatters {
+		t.Run(name, func(t *testing.T) {
+			assertGolden(t, "formatter_"+name, []byte(formatter.format("*PECHUGA DE POLLO* 🐔\n* 🔢 *Código:* 101")))
+		})
+	}
+}
+
+func TestChatCompletionsStream(t *testing.T) {
+	provider := newFakeProvider(
+		scriptedTurn{
+			ToolCalls: []ToolCall{{ID: "call-1", Name: "obtenerInformacionPorCodigo", Args: map[string]any{"productCodes": []any{"101"}}}},
+			Usage:     tokenUsage{PromptTokens: 100, CandidatesTokens: 10, TotalTokens: 110},
+		},
+		scriptedTurn{
+			Text:  "La pechuga está a $95.50 por Kg.",
+			Usage: tokenUsage{PromptTokens: 300, CandidatesTokens: 20, TotalTokens: 320},
+		},
+	)
+	srv := newTestServer(t, provider)
+
+	req := userRequest("precio de la pechuga")
+	req.Stream = true
+	req.StreamOptions = &OpenAIStreamOptions{IncludeUsage: true}
+	resp, body := postChat(t, srv, testAPIKey, req)
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", got)
+	}
+
+	var content strings.Builder
+	var usage *OpenAIUsage
+	var events []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		events = append(events, data)
+		if data == "[DONE]" {
+			continue
+		}
+		var chunk OpenAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		if chunk.Object != "chat.completion.chunk" {
+			t.Errorf("unexpected object %q", chunk.Object)
+		}
+		for _, choice := range chunk.Choices {
+			content.WriteString(choice.Delta.Content)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+
+	if last := events[len(events)-1]; last != "[DONE]" {
+		t.Errorf("stream must end with [DONE], got %q", last)
+	}
+	if want := responseFormatters["whatsapp"].format("La pechuga está a $95.50 por Kg."); content.String() != want {
+		t.Errorf("unexpected streamed content\n--- got\n%s\n--- want\n%s", content.String(), want)
+	}
+	if usage == nil || usage.TotalTokens != 430 || usage.PromptTokens != 400 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+}
+
+func TestChatCompletionsRejectsUnknownAPIKey(t *testing.T) {
+	srv := newTestServer(t, newFakeProvider())
+
+	resp, body := postChat(t, srv, "wrong-key", userRequest("hola"))
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	var errResp OpenAIErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		t.Fatal(err)
+	}
+	if errResp.Error.Code != "invalid_api_key" {
+		t.Errorf("unexpected error %+v", errResp.Error)
+	}
+}
+
+func TestChatCompletionsUnknownModel(t *testing.T) {
+	srv := newTestServer(t, newFakeProvider())
+
+	req := userRequest("hola")
+	req.Model = "gpt-4"
+	resp, _ := postChat(t, srv, testAPIKey, req)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+}
@@ -9,6 +9,71 @@ import (
 	"time"
 )
 
+type AiApiKey struct {
+	KeyHash  string
+	Usuario  string
+	Nombre   string
+	Sucursal string
+	Rol      string
+	Activo   bool
+	Creado   time.Time
+}
+
+type AiQuote struct {
+	ID       int64
+	Cliente  string
+	Notas    string
+	Usuario  string
+	Sucursal string
+	Subtotal float64
+	Iva      float64
+	Total    float64
+	Creada   time.Time
+	Vence    time.Time
+}
+
+type AiQuoteApproval struct {
+	ID         int64
+	QuoteID    int64
+	Usuario    string
+	Motivos    string
+	Estado     string
+	Revisor    string
+	Comentario string
+	Creada     time.Time
+	Resuelta   sql.NullTime
+	Notificada bool
+}
+
+type AiQuoteLine struct {
+	ID          int64
+	QuoteID     int64
+	Codigo      string
+	Descripcion string
+	Cantidad    float64
+	Unidad      string
+	Kg          float64
+	Lista       string
+	PrecioKg    float64
+	Importe     float64
+	TasaIva     float64
+	Iva         float64
+}
+
+type AiStockReservation struct {
+	ID            int64
+	Codigo        string
+	Descripcion   string
+	Kg            float64
+	Cliente       string
+	NumeroCliente string
+	Usuario       string
+	Sucursal      string
+	Creada        time.Time
+	Vence         time.Time
+	Liberada      sql.NullTime
+}
+
 type Articulo struct {
 	Vcodpro   string
 	Vcodaux   string
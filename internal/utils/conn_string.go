@@ -7,7 +7,8 @@ import (
 
 func GetConnString() string {
 	return fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s",
+		// parseTime scans DATETIME columns into time.Time, in the local time zone they are written in
+		"%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=Local",
 		os.Getenv("DB_USER"),
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_HOST"),
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// scriptedTurn is one recorded answer of the model: tool calls, or the final text when there are none
+type scriptedTurn struct {
+	Text      string
+	ToolCalls []ToolCall
+	Usage     tokenUsage
+}
+
+// fakeProvider is a deterministic LLMProvider that replays scripted turns in order
+// and keeps every request it receives for later assertions
+type fakeProvider struct {
+	mu       sync.Mutex
+	turns    []scriptedTurn
+	requests []LLMRequest
+}
+
+func newFakeProvider(turns ...scriptedTurn) *fakeProvider {
+	return &fakeProvider{turns: turns}
+}
+
+func (p *fakeProvider) Generate(ctx context.Context, req LLMRequest, onText func(string)) (LLMResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	req.Messages = append([]LLMMessage(nil), req.Messages...)
+	p.requests = append(p.requests, req)
+
+	if err := ctx.Err(); err != nil {
+		return LLMResponse{}, err
+	}
+	if len(p.turns) == 0 {
+		return LLMResponse{}, fmt.Errorf("fake provider: no scripted turn left for request %d", len(p.requests))
+	}
+	turn := p.turns[0]
+	p.turns = p.turns[1:]
+
+	// Stream the text word by word, like a real provider sends deltas
+	if onText != nil {
+		for _, word := range strings.SplitAfter(turn.Text, " ") {
+			if word != "" {
+				onText(word)
+			}
+		}
+	}
+
+	return LLMResponse{
+		Text:      turn.Text,
+		ToolCalls: turn.ToolCalls,
+		Usage:     turn.Usage,
+	}, nil
+}
+
+// toolResults returns the tool results sent back to the model, in the order they were sent
+func (p *fakeProvider) toolResults() []ToolResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.requests) == 0 {
+		return nil
+	}
+	var results []ToolResult
+	for _, msg := range p.requests[len(p.requests)-1].Messages {
+		results = append(results, msg.ToolResults...)
+	}
+	return results
+}
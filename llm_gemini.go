@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// geminiProvider implements LLMProvider with the Gemini streaming API
+type geminiProvider struct {
+	client *genai.Client
+}
+
+func (p *geminiProvider) Generate(ctx context.Context, req LLMRequest, onText func(string)) (LLMResponse, error) {
+	config := &genai.GenerateContentConfig{
+		Temperature: req.Temperature,
+		SystemInstruction: &genai.Content{
+			Parts: []*genai.Part{{Text: req.SystemPrompt}},
+		},
+	}
+	if len(req.Tools) > 0 {
+		config.Tools = []*genai.Tool{
+			{
+				FunctionDeclarations: toGeminiDeclarations(req.Tools),
+			},
+		}
+	}
+
+	var resp LLMResponse
+	var text strings.Builder
+	var usage *genai.GenerateContentResponseUsageMetadata
+
+	for chunk, err := range p.client.Models.GenerateContentStream(ctx, req.Model, toGeminiContents(req.Messages), config) {
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return resp, err
+		}
+		// Usage metadata is cumulative, the last chunk has the totals of the call
+		if chunk.UsageMetadata != nil {
+			usage = chunk.UsageMetadata
+		}
+
+		resp.ToolCalls = append(resp.ToolCalls, fromGeminiToolCalls(chunk)...)
+
+		chunkText := chunk.Text()
+		text.WriteString(chunkText)
+		if onText != nil && chunkText != "" {
+			onText(chunkText)
+		}
+	}
+
+	resp.Text = text.String()
+	resp.Usage.add(usage)
+	return resp, nil
+}
+
+// fromGeminiToolCalls reads the function calls of a chunk with the thought signature of their
+// part, which thinking models require back with the call in the next round
+func fromGeminiToolCalls(chunk *genai.GenerateContentResponse) []ToolCall {
+	if len(chunk.Candidates) == 0 || chunk.Candidates[0].Content == nil {
+		return nil
+	}
+	var calls []ToolCall
+	for _, part := range chunk.Candidates[0].Content.Parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+		calls = append(calls, ToolCall{
+			ID:        part.FunctionCall.ID,
+			Name:      part.FunctionCall.Name,
+			Args:      part.FunctionCall.Args,
+			Signature: part.ThoughtSignature,
+		})
+	}
+	return calls
+}
+
+func toGeminiContents(messages []LLMMessage) []*genai.Content {
+	var contents []*genai.Content
+	for _, msg := range messages {
+		switch msg.Role {
+		case llmRoleAssistant:
+			content := &genai.Content{Role: genai.RoleModel}
+			if msg.Text != "" {
+				content.Parts = append(content.Parts, &genai.Part{Text: msg.Text})
+			}
+			for _, call := range msg.ToolCalls {
+				content.Parts = append(content.Parts, &genai.Part{
+					FunctionCall:     &genai.FunctionCall{ID: call.ID, Name: call.Name, Args: call.Args},
+					ThoughtSignature: call.Signature,
+				})
+			}
+			contents = append(contents, content)
+		case llmRoleTool:
+			content := &genai.Content{Role: genai.RoleUser}
+			for _, result := range msg.ToolResults {
+				content.Parts = append(content.Parts, &genai.Part{
+					FunctionResponse: &genai.FunctionResponse{
+						ID:       result.CallID,
+						Name:     result.Name,
+						Response: result.Response,
+					},
+				})
+			}
+			contents = append(contents, content)
+		default:
+			contents = append(contents, genai.NewContentFromText(msg.Text, genai.RoleUser))
+		}
+	}
+	return contents
+}
+
+func toGeminiDeclarations(tools []*ToolDeclaration) []*genai.FunctionDeclaration {
+	var declarations []*genai.FunctionDeclaration
+	for _, tool := range tools {
+		declarations = append(declarations, &genai.FunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  toGeminiSchema(tool.Parameters),
+		})
+	}
+	return declarations
+}
+
+func toGeminiSchema(schema *Schema) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+	gs := &genai.Schema{
+		Type:        genai.Type(strings.ToUpper(schema.Type)),
+		Description: schema.Description,
+		Items:       toGeminiSchema(schema.Items),
+		Required:    schema.Required,
+		Enum:        schema.Enum,
+	}
+	if len(schema.Properties) > 0 {
+		gs.Properties = make(map[string]*genai.Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			gs.Properties[name] = toGeminiSchema(property)
+		}
+	}
+	return gs
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+// The thought signature of a function call goes back to Gemini with the call in the next round
+func TestGeminiToolCallSignature(t *testing.T) {
+	chunk := &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: &genai.Content{
+		Role: genai.RoleModel,
+		Parts: []*genai.Part{{
+			FunctionCall:     &genai.FunctionCall{ID: "call-1", Name: "cotizar", Args: map[string]any{"productos": []any{}}},
+			ThoughtSignature: []byte("firma"),
+		}},
+	}}}}
+
+	calls := fromGeminiToolCalls(chunk)
+	if len(calls) != 1 || calls[0].Name != "cotizar" || !bytes.Equal(calls[0].Signature, []byte("firma")) {
+		t.Fatalf("unexpected tool calls %+v", calls)
+	}
+
+	contents := toGeminiContents([]LLMMessage{{Role: llmRoleAssistant, ToolCalls: calls}})
+	part := contents[0].Parts[0]
+	if part.FunctionCall == nil || part.FunctionCall.ID != "call-1" || !bytes.Equal(part.ThoughtSignature, []byte("firma")) {
+		t.Errorf("the signature was not sent back: %+v", part)
+	}
+}
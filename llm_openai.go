@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// openAIProvider implements LLMProvider against any OpenAI-compatible
+// /chat/completions endpoint, such as a local Ollama or llama.cpp server
+type openAIProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newOpenAIProvider(baseURL, apiKey string) *openAIProvider {
+	return &openAIProvider{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+	}
+}
+
+// Request and response bodies of the OpenAI-compatible client. They are separate from
+// the OpenAI structs of the server side because they also carry tool calls.
+
+type oaiChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []oaiMessage         `json:"messages"`
+	Tools         []oaiTool            `json:"tools,omitempty"`
+	Temperature   *float32             `json:"temperature,omitempty"`
+	Stream        bool                 `json:"stream"`
+	StreamOptions *OpenAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type oaiMessage struct {
+	Role       string        `json:"role"`
+	Content    string        `json:"content"`
+	ToolCalls  []oaiToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+}
+
+type oaiTool struct {
+	Type     string          `json:"type"`
+	Function oaiToolFunction `json:"function"`
+}
+
+type oaiToolFunction struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Parameters  *Schema `json:"parameters,omitempty"`
+}
+
+type oaiToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type oaiStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string        `json:"content"`
+			ToolCalls []oaiToolCall `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage"`
+	Error *OpenAIError `json:"error"`
+}
+
+func (p *openAIProvider) Generate(ctx context.Context, req LLMRequest, onText func(string)) (LLMResponse, error) {
+	body, err := json.Marshal(oaiChatRequest{
+		Model:         req.Model,
+		Messages:      toOpenAIMessages(req.SystemPrompt, req.Messages),
+		Tools:         toOpenAITools(req.Tools),
+		Temperature:   req.Temperature,
+		Stream:        true,
+		StreamOptions: &OpenAIStreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	httpResp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return LLMResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		errBody, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		return LLMResponse{}, fmt.Errorf("unexpected status %s: %s", httpResp.Status, errBody)
+	}
+
+	return readOpenAIStream(httpResp.Body, onText)
+}
+
+// readOpenAIStream reads the chat.completion.chunk events, joining the text deltas
+// and the tool call fragments, which arrive split by index
+func readOpenAIStream(body io.Reader, onText func(string)) (LLMResponse, error) {
+	var resp LLMResponse
+	var text strings.Builder
+	var calls []*oaiToolCall
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk oaiStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return resp, fmt.Errorf("failed to parse chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return resp, fmt.Errorf("provider error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			resp.Usage = tokenUsage{
+				PromptTokens:     chunk.Usage.PromptTokens,
+				CandidatesTokens: chunk.Usage.CompletionTokens,
+				TotalTokens:      chunk.Usage.TotalTokens,
+			}
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				if onText != nil {
+					onText(choice.Delta.Content)
+				}
+			}
+			for _, fragment := range choice.Delta.ToolCalls {
+				for len(calls) <= fragment.Index {
+					calls = append(calls, &oaiToolCall{})
+				}
+				call := calls[fragment.Index]
+				if fragment.ID != "" {
+					call.ID = fragment.ID
+				}
+				call.Function.Name += fragment.Function.Name
+				call.Function.Arguments += fragment.Function.Arguments
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return resp, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	resp.Text = text.String()
+	for _, call := range calls {
+		args := map[string]any{}
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return resp, fmt.Errorf("invalid arguments for %s: %w", call.Function.Name, err)
+			}
+		}
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+			ID:   call.ID,
+			Name: call.Function.Name,
+			Args: args,
+		})
+	}
+
+	return resp, nil
+}
+
+func toOpenAIMessages(systemPrompt string, messages []LLMMessage) []oaiMessage {
+	result := []oaiMessage{{Role: "system", Content: systemPrompt}}
+	for _, msg := range messages {
+		switch msg.Role {
+		case llmRoleAssistant:
+			oaiMsg := oaiMessage{Role: "assistant", Content: msg.Text}
+			for i, call := range msg.ToolCalls {
+				args, _ := json.Marshal(call.Args)
+				toolCall := oaiToolCall{Index: i, ID: call.ID, Type: "function"}
+				toolCall.Function.Name = call.Name
+				toolCall.Function.Arguments = string(args)
+				oaiMsg.ToolCalls = append(oaiMsg.ToolCalls, toolCall)
+			}
+			result = append(result, oaiMsg)
+		case llmRoleTool:
+			for _, toolResult := range msg.ToolResults {
+				content, _ := json.Marshal(toolResult.Response)
+				result = append(result, oaiMessage{
+					Role:       "tool",
+					Content:    string(content),
+					ToolCallID: toolResult.CallID,
+				})
+			}
+		default:
+			result = append(result, oaiMessage{Role: "user", Content: msg.Text})
+		}
+	}
+	return result
+}
+
+func toOpenAITools(tools []*ToolDeclaration) []oaiTool {
+	var result []oaiTool
+	for _, tool := range tools {
+		result = append(result, oaiTool{
+			Type: "function",
+			Function: oaiToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return result
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// LLMProvider is a language model backend able to call tools. The tool loop in
+// processUserQuery only talks to this interface, never to a specific SDK.
+type LLMProvider interface {
+	// Generate answers the conversation in req. Text deltas are passed to onText
+	// as they arrive when onText is not nil.
+	Generate(ctx context.Context, req LLMRequest, onText func(string)) (LLMResponse, error)
+}
+
+// Roles of the messages in a provider-neutral conversation
+const (
+	llmRoleUser      = "user"
+	llmRoleAssistant = "assistant"
+	// Message carrying the results of the tool calls of the previous assistant message
+	llmRoleTool = "tool"
+)
+
+type LLMRequest struct {
+	Model        string
+	SystemPrompt string
+	Messages     []LLMMessage
+	Tools        []*ToolDeclaration
+	Temperature  *float32
+}
+
+type LLMMessage struct {
+	Role        string       `json:"role"`
+	Text        string       `json:"text,omitempty"`
+	ToolCalls   []ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+}
+
+type LLMResponse struct {
+	Text      string
+	ToolCalls []ToolCall
+	Usage     tokenUsage
+}
+
+// ToolCall is a request from the model to run a tool
+type ToolCall struct {
+	ID   string         `json:"id,omitempty"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args"`
+	// Opaque signature of the provider, e.g. the Gemini thought signature, sent back with the call
+	Signature []byte `json:"signature,omitempty"`
+}
+
+// ToolResult is the answer to a ToolCall, either {"result": ...} or a structured error
+type ToolResult struct {
+	CallID   string         `json:"call_id,omitempty"`
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
+}
+
+// ToolDeclaration describes a tool to the model
+type ToolDeclaration struct {
+	Name        string
+	Description string
+	Parameters  *Schema
+}
+
+// Schema is a subset of JSON Schema, enough to describe tool arguments.
+// It marshals to the JSON Schema expected by OpenAI-compatible servers.
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+}
+
+// Schema types
+const (
+	schemaObject  = "object"
+	schemaArray   = "array"
+	schemaString  = "string"
+	schemaNumber  = "number"
+	schemaInteger = "integer"
+	schemaBoolean = "boolean"
+)
+
+// newProviders builds the providers available to the profiles, by name
+func newProviders(cfg Config, gemini LLMProvider) map[string]LLMProvider {
+	providers := map[string]LLMProvider{
+		"gemini": gemini,
+	}
+	if cfg.OpenAICompatBaseURL != "" {
+		providers["openai"] = newOpenAIProvider(cfg.OpenAICompatBaseURL, cfg.OpenAICompatAPIKey)
+	}
+	return providers
+}
+
+func getProvider(providers map[string]LLMProvider, name string) (LLMProvider, error) {
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown or unconfigured provider %q", name)
+	}
+	return provider, nil
+}
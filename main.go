@@ -1,45 +1,58 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
-
-	// For API key
-
-	"github.com/joho/godotenv"
-)
-
-var (
-	GeminiKey   string
-	GeminiModel string
-	APIPort     string
+	"os/signal"
+	"syscall"
 )
 
-var ToolFunctions = getCompletionTools()
-
 func main() {
-	err := loadEnv()
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	http.HandleFunc("/", handlerGeneric)
-	http.HandleFunc("/v1/chat/completions", chatCompletionsHandler)
+	// Cancelled on SIGINT or SIGTERM, which stops the stock digests and shuts the server down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	log.Printf("Server starting on port%s...\n", APIPort)
-	log.Fatal(http.ListenAndServe(APIPort, nil))
-}
-
-func loadEnv() error {
-	err := godotenv.Load()
+	app, err := newApp(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to load .env file: %w", err)
+		log.Fatal(err)
 	}
-	GeminiKey = os.Getenv("GEMINI_API_KEY")
-	GeminiModel = os.Getenv("GEMINI_MODEL")
-	APIPort = fmt.Sprintf(":%v", os.Getenv("API_PORT"))
-
-	return nil
+	defer app.Close()
+
+	go app.runStockDigests(ctx)
+
+	// ListenAndServe returns as soon as Shutdown starts, main waits on idleConnsClosed so the
+	// requests in flight finish before the database is closed
+	srv := &http.Server{Addr: cfg.APIPort, Handler: app.routes()}
+	idleConnsClosed := make(chan struct{})
+	go func() {
+		defer close(idleConnsClosed)
+		<-ctx.Done()
+		log.Println("shutting down server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.RequestTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("failed to shut down server: %v\n", err)
+		}
+	}()
+
+	log.Printf("Server starting on port%s...\n", cfg.APIPort)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	<-idleConnsClosed
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"slices"
+	"strings"
+)
+
+const costMarginToolName = "obtenerCostoMargen"
+
+type proposedPriceArgs struct {
+	Codigo string  `json:"codigo" desc:"Código del producto."`
+	Precio float64 `json:"precio" desc:"Precio por kg propuesto por el vendedor."`
+}
+
+type costMarginArgs struct {
+	ProductCodes      []string            `json:"productCodes" desc:"Lista de códigos de productos (strings)."`
+	PreciosPropuestos []proposedPriceArgs `json:"preciosPropuestos,omitempty" desc:"Precios por kg que se quieren ofrecer, para calcular su margen."`
+}
+
+// priceMargin is the profit of selling at a price, over the last cost of the product
+type priceMargin struct {
+	Lista      string
+	Precio     float64
+	UtilidadKg float64
+	// Margin over the sale price, in percent
+	MargenPct float64
+}
+
+// productMargin holds the costs of a product and its margin at every Grupo tier and at the
+// proposed prices. Products without a cost in the ERP have no margins.
+type productMargin struct {
+	Codigo      string
+	Descripcion string
+	// Vcospr1, the cost of the last purchase, the margins are taken over it
+	CostoUltimo float64
+	// Vcospr2, the cost before the last purchase
+	CostoAnterior    float64
+	PrecioActual     float64
+	FactorReferencia float64
+	SinCosto         bool
+	Niveles          []priceMargin
+	Propuestos       []priceMargin
+}
+
+// costMarginTool returns the cost and margin tool. It is only offered to managers, the costs
+// must never reach the sales profiles or the customers.
+func costMarginTool() FunctionTool {
+	tool := newFunctionTool(costMarginToolName,
+		"Solo para gerentes. Devuelve el último costo de los productos y el margen y la utilidad por kg "+
+			"en detalle, medio mayoreo, mayoreo y en los precios propuestos, para saber hasta cuánto se puede bajar el precio.",
+		getCostMargins)
+	tool.ManagerOnly = true
+	return tool
+}
+
+func getCostMargins(ctx context.Context, queries *database.Queries, args costMarginArgs) (any, error) {
+	codes := args.ProductCodes
+	for _, proposed := range args.PreciosPropuestos {
+		if proposed.Precio <= 0 {
+			return nil, invalidArgumentError("el precio propuesto para %s debe ser mayor a cero", proposed.Codigo)
+		}
+		if !slices.Contains(codes, proposed.Codigo) {
+			codes = append(codes, proposed.Codigo)
+		}
+	}
+	if len(codes) == 0 {
+		return nil, invalidArgumentError("productCodes debe incluir al menos un código")
+	}
+
+	rows, err := queries.GetProductCosts(ctx, codes)
+	if err != nil {
+		return nil, executionError("ocurrió un error al obtener los costos de los productos", err)
+	}
+	products := map[string]database.GetProductCostsRow{}
+	for _, row := range rows {
+		products[row.Codigo] = row
+	}
+
+	var margins []productMargin
+	var missing []string
+	for _, code := range codes {
+		row, ok := products[code]
+		if !ok {
+			missing = append(missing, code)
+			continue
+		}
+
+		margin := productMargin{
+			Codigo:           row.Codigo,
+			Descripcion:      row.Descripcion,
+			CostoUltimo:      row.CostoUltimo,
+			CostoAnterior:    row.CostoAnterior,
+			PrecioActual:     row.PrecioActual,
+			FactorReferencia: row.FactorReferencia,
+			SinCosto:         row.CostoUltimo <= 0,
+		}
+		if !margin.SinCosto {
+			for _, tier := range []struct {
+				list  string
+				price float64
+			}{
+				{priceListRetail, row.PrecioDetalle},
+				{priceListHalfSale, row.PrecioMedioMayoreo},
+				{priceListWholesale, row.PrecioMayoreo},
+			} {
+				if tier.price > 0 {
+					margin.Niveles = append(margin.Niveles, marginAt(tier.list, tier.price, row.CostoUltimo))
+				}
+			}
+			for _, proposed := range args.PreciosPropuestos {
+				if proposed.Codigo == code {
+					margin.Propuestos = append(margin.Propuestos, marginAt("propuesto", proposed.Precio, row.CostoUltimo))
+				}
+			}
+		}
+		margins = append(margins, margin)
+	}
+	if len(missing) > 0 {
+		return nil, invalidArgumentError("no se encontraron los códigos %s", strings.Join(missing, ", "))
+	}
+	return margins, nil
+}
+
+func marginAt(list string, price, cost float64) priceMargin {
+	return priceMargin{
+		Lista:      list,
+		Precio:     price,
+		UtilidadKg: roundCents(price - cost),
+		MargenPct:  roundCents((price - cost) / price * 100),
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// The costs must not reach a sales rep, even on a profile that lists the tool, nor a profile that does not list it
+func TestCostMarginToolIsManagerOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		apiKey  string
+		profile string
+	}{
+		{"sales rep on the manager profile", testAPIKey, testManagerProfile},
+		{"manager on the default profile", testManagerAPIKey, "COPO-AI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newFakeProvider(
+				scriptedTurn{ToolCalls: []ToolCall{{ID: "call-1", Name: costMarginToolName, Args: map[string]any{"productCodes": []any{"101"}}}}},
+				scriptedTurn{Text: "No tengo acceso a los costos."},
+			)
+			srv := newTestServer(t, provider)
+
+			req := userRequest("¿hasta cuánto le puedo bajar a la pechuga?")
+			req.Model = tt.profile
+			resp, body := postChat(t, srv, tt.apiKey, req)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+			}
+
+			for _, declaration := range provider.requests[0].Tools {
+				if declaration.Name == costMarginToolName {
+					t.Errorf("%s was offered to the model", costMarginToolName)
+				}
+			}
+			results := provider.toolResults()
+			if len(results) != 1 {
+				t.Fatalf("got %d tool results, want 1", len(results))
+			}
+			toolErr, _ := results[0].Response["error"].(map[string]any)
+			if toolErr["code"] != toolErrUnknownTool {
+				t.Errorf("got tool response %v, want an %s error", results[0].Response, toolErrUnknownTool)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// notification is a message the agent sends on its own, such as the daily stock digest.
+// Text is ready to read in a chat and Data carries the same content for programs.
+type notification struct {
+	Kind  string    `json:"kind"`
+	Title string    `json:"title"`
+	Text  string    `json:"text"`
+	Time  time.Time `json:"time"`
+	Data  any       `json:"data,omitempty"`
+}
+
+// Notifier delivers the notifications of the agent
+type Notifier interface {
+	Notify(ctx context.Context, n notification) error
+}
+
+// newNotifier builds the notifier selected by the configuration
+func newNotifier(cfg Config) (Notifier, error) {
+	switch cfg.Notifier {
+	case "log":
+		return logNotifier{}, nil
+	case "webhook":
+		if cfg.NotifierWebhookURL == "" {
+			return nil, fmt.Errorf("NOTIFIER_WEBHOOK_URL is required with NOTIFIER=webhook")
+		}
+		return &webhookNotifier{url: cfg.NotifierWebhookURL, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	case "file":
+		if cfg.NotifierFile == "" {
+			return nil, fmt.Errorf("NOTIFIER_FILE is required with NOTIFIER=file")
+		}
+		return &fileNotifier{path: cfg.NotifierFile}, nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER %q, use log, webhook or file", cfg.Notifier)
+	}
+}
+
+// logNotifier writes the notifications to the server log
+type logNotifier struct{}
+
+func (logNotifier) Notify(ctx context.Context, n notification) error {
+	log.Printf("%s:\n%s\n", n.Title, n.Text)
+	return nil
+}
+
+// webhookNotifier posts every notification as JSON, e.g. to a chat bot or an automation service
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (wn *webhookNotifier) Notify(ctx context.Context, n notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wn.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wn.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// fileNotifier appends every notification to a JSONL file
+type fileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func (fn *fileNotifier) Notify(ctx context.Context, n notification) error {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	file, err := os.OpenFile(fn.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open notifications file: %w", err)
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(n); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// OpenAI-style error object: {"error": {"message": ..., "type": ..., "code": ...}}
+
+type OpenAIErrorResponse struct {
+	Error OpenAIError `json:"error"`
+}
+
+type OpenAIError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    string  `json:"code,omitempty"`
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, errType, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(OpenAIErrorResponse{
+		Error: OpenAIError{
+			Message: message,
+			Type:    errType,
+			Code:    code,
+		},
+	})
+}
+
+// processingError maps an error from processUserQuery to the status and error object returned to the client
+func processingError(err error) (int, OpenAIError) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout, OpenAIError{
+			Message: "The request took too long to complete, try again",
+			Type:    "timeout_error",
+			Code:    "timeout",
+		}
+	}
+	return http.StatusInternalServerError, OpenAIError{
+		Message: "Failed to get response from the model",
+		Type:    "server_error",
+		Code:    "internal_error",
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Fonts of the generated PDFs. Only the standard Type1 fonts are used, so nothing is embedded.
+const (
+	pdfFontMono = "F1"
+	pdfFontBold = "F2"
+)
+
+// Letter page with the same margin on every side, in points
+const (
+	pdfPageWidth  = 612
+	pdfPageHeight = 792
+	pdfMargin     = 40
+)
+
+// pdfLine is a line of text of a generated PDF, an empty Text leaves a blank line
+type pdfLine struct {
+	Font string
+	Size float64
+	Text string
+}
+
+// writeTextPDF lays out the lines top to bottom, starting a new page when one is full,
+// and returns a minimal PDF 1.4 document
+func writeTextPDF(lines []pdfLine) []byte {
+	var pages []string
+	var content strings.Builder
+	y := float64(pdfPageHeight - pdfMargin)
+	for _, line := range lines {
+		leading := line.Size * 1.4
+		if y-leading < pdfMargin && content.Len() > 0 {
+			pages = append(pages, content.String())
+			content.Reset()
+			y = pdfPageHeight - pdfMargin
+		}
+		y -= leading
+		if line.Text != "" {
+			fmt.Fprintf(&content, "BT /%s %.1f Tf %d %.2f Td (%s) Tj ET\n", line.Font, line.Size, pdfMargin, y, pdfString(line.Text))
+		}
+	}
+	pages = append(pages, content.String())
+
+	// Objects: 1 catalog, 2 page tree, 3 and 4 fonts, then a page and its content stream per page
+	var objects []string
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+2*i))
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pdfFontMono, pdfFontBold, 6+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(page), page),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// Characters of WinAnsiEncoding outside Latin-1 that show up in our texts
+var pdfWinAnsi = map[rune]byte{
+	'€': 0x80, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// pdfString encodes text as a PDF literal string in WinAnsiEncoding. Characters the
+// standard fonts can not show, like emojis, are dropped.
+func pdfString(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		var b byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			out.WriteByte('\\')
+			out.WriteRune(r)
+			continue
+		case r == '\t' || r == '\n' || r == '\r':
+			b = ' '
+		case r >= 0x20 && r < 0x7f:
+			b = byte(r)
+		case r >= 0xa0 && r <= 0xff:
+			b = byte(r)
+		default:
+			var ok bool
+			if b, ok = pdfWinAnsi[r]; !ok {
+				continue
+			}
+		}
+		if b < 0x80 {
+			out.WriteByte(b)
+		} else {
+			fmt.Fprintf(&out, "\\%03o", b)
+		}
+	}
+	return out.String()
+}
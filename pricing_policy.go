@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// pricingPolicy is the minimum margin over the last cost (Vcospr1) every price offered by the
+// agent must keep, in percent. A brand rule wins over a line rule, which wins over the default.
+// Independently of the margins, no price may go below the mayoreo price (Fac3) without approval.
+type pricingPolicy struct {
+	MinMarginPct float64            `json:"min_margin_pct"`
+	Lineas       map[string]float64 `json:"lineas"`
+	Marcas       map[string]float64 `json:"marcas"`
+}
+
+// loadPricingPolicy reads the policy from a JSON file, an empty path only keeps prices
+// at or above the mayoreo price and the cost:
+//
+//	{"min_margin_pct": 5, "lineas": {"POLLO": 8}, "marcas": {"FUD": 12}}
+func loadPricingPolicy(path string) (pricingPolicy, error) {
+	if path == "" {
+		return pricingPolicy{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pricingPolicy{}, fmt.Errorf("failed to read pricing policy file: %w", err)
+	}
+	var policy pricingPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return pricingPolicy{}, fmt.Errorf("failed to parse pricing policy file: %w", err)
+	}
+
+	// Lines and brands are matched without regard to case or surrounding spaces
+	policy.Lineas = normalizePolicyKeys(policy.Lineas)
+	policy.Marcas = normalizePolicyKeys(policy.Marcas)
+	margins := []float64{policy.MinMarginPct}
+	for _, margin := range policy.Lineas {
+		margins = append(margins, margin)
+	}
+	for _, margin := range policy.Marcas {
+		margins = append(margins, margin)
+	}
+	for _, margin := range margins {
+		if margin < 0 || margin >= 100 {
+			return pricingPolicy{}, fmt.Errorf("pricing policy margins must be between 0 and 100, got %v", margin)
+		}
+	}
+	return policy, nil
+}
+
+func normalizePolicyKeys(margins map[string]float64) map[string]float64 {
+	normalized := make(map[string]float64, len(margins))
+	for key, margin := range margins {
+		normalized[strings.ToUpper(strings.TrimSpace(key))] = margin
+	}
+	return normalized
+}
+
+// minMargin returns the minimum margin in percent for a product of the line and brand
+func (p pricingPolicy) minMargin(linea, marca string) float64 {
+	if margin, ok := p.Marcas[strings.ToUpper(strings.TrimSpace(marca))]; ok {
+		return margin
+	}
+	if margin, ok := p.Lineas[strings.ToUpper(strings.TrimSpace(linea))]; ok {
+		return margin
+	}
+	return p.MinMarginPct
+}
+
+// policyPrice is a price per kg the agent is about to offer
+type policyPrice struct {
+	Codigo string
+	Precio float64
+}
+
+// policyViolation is a price that needs the approval of a manager. Motivo is shown to the
+// rep and never carries the cost or the margin, which only go in the approval request.
+type policyViolation struct {
+	Codigo      string
+	Descripcion string
+	Precio      float64
+	Motivo      string
+	detail      string
+}
+
+// check returns the prices that break the policy. Prices of codes that are not found are skipped,
+// the callers already report them.
+func (p pricingPolicy) check(ctx context.Context, queries *database.Queries, prices []policyPrice) ([]policyViolation, error) {
+	if len(prices) == 0 {
+		return nil, nil
+	}
+
+	var codes []string
+	for _, price := range prices {
+		codes = append(codes, price.Codigo)
+	}
+	rows, err := queries.GetProductPolicyData(ctx, codes)
+	if err != nil {
+		return nil, executionError("ocurrió un error al revisar la política de precios", err)
+	}
+	products := map[string]database.GetProductPolicyDataRow{}
+	for _, row := range rows {
+		products[row.Codigo] = row
+	}
+
+	var violations []policyViolation
+	for _, price := range prices {
+		product, ok := products[price.Codigo]
+		if !ok {
+			continue
+		}
+
+		var reasons, details []string
+		if product.PrecioMayoreo > 0 && price.Precio < product.PrecioMayoreo {
+			reasons = append(reasons, "debajo del precio de mayoreo")
+			details = append(details, fmt.Sprintf("mayoreo %s", formatMoney(product.PrecioMayoreo)))
+		}
+		if product.CostoUltimo > 0 {
+			margin := (price.Precio - product.CostoUltimo) / price.Precio * 100
+			minimum := p.minMargin(product.Linea, product.Marca)
+			switch {
+			case price.Precio < product.CostoUltimo:
+				reasons = append(reasons, "debajo del costo")
+			case margin < minimum:
+				reasons = append(reasons, "margen menor al autorizado")
+			}
+			if margin < minimum {
+				details = append(details, fmt.Sprintf("costo %s, margen %.2f%% (mínimo %.2f%%)", formatMoney(product.CostoUltimo), margin, minimum))
+			}
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		violations = append(violations, policyViolation{
+			Codigo:      product.Codigo,
+			Descripcion: product.Descripcion,
+			Precio:      price.Precio,
+			Motivo:      strings.Join(reasons, ", "),
+			detail:      strings.Join(details, "; "),
+		})
+	}
+	return violations, nil
+}
+
+// approvalReasons writes the violations for the manager that reviews the quote, one per line
+func approvalReasons(violations []policyViolation) string {
+	var lines []string
+	for _, v := range violations {
+		lines = append(lines, fmt.Sprintf("%s %s a %s/kg: %s (%s)", v.Codigo, v.Descripcion, formatMoney(v.Precio), v.Motivo, v.detail))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Name of the tool the model calls with the products chosen for the answer.
+// The cards are rendered from the database rows, the model never writes prices or stock.
+const showProductsTool = "mostrarProductos"
+
+// defaultCardTemplate renders the WhatsApp product card used by the counter profiles
+const defaultCardTemplate = `{{range $i, $p := .}}{{if $i}}
+
+{{end}}*{{$p.Descripcion}}* {{$p.Emoji}}
+* 🔢 *Código:* {{$p.Codigo}}
+* ® *Marca:* {{$p.Marca}}
+* 📦 *Peso prom. caja:* {{kg $p.PesoPromedioCajaKg}} Kg
+* 📦 *Piezas x caja:* {{$p.PiezasPorCaja}}
+* ⚖ *Peso prom. pieza:* {{kg $p.PesoPiezaKg}} Kg
+* 💲 *Precios por Kg:*
+{{range $p.Precios}}  * {{.Emoji}} *{{.Nombre}}:* {{money .Precio}} ({{.Rango}})
+{{end}}* 📥 *Existencia Kg:* {{kg $p.ExistenciaKg}} Kg{{end}}`
+
+var cardTemplateFuncs = template.FuncMap{
+	"kg":    func(value float64) string { return fmt.Sprintf("%.2f", value) },
+	"money": func(value float64) string { return fmt.Sprintf("$%.2f", value) },
+}
+
+var defaultCards = template.Must(parseCardTemplate("default", defaultCardTemplate))
+
+// productCard is the data of a product card template: the database row plus the
+// values computed in code, so templates never do rounding or tier math
+type productCard struct {
+	database.GetProductsInfoByCodeRow
+	Emoji       string
+	PesoPiezaKg float64
+	// Price tiers with a price, from detalle to mayoreo
+	Precios []priceTier
+}
+
+type priceTier struct {
+	Nombre string
+	Emoji  string
+	Precio float64
+	// Quantity range of the tier, e.g. "hasta 30 Kg" or "30-100 Kg"
+	Rango string
+}
+
+func newProductCard(row database.GetProductsInfoByCodeRow) productCard {
+	card := productCard{
+		GetProductsInfoByCodeRow: row,
+		Emoji:                    productEmoji(row.Descripcion),
+	}
+	card.PesoPiezaKg, _ = anyFloat(row.PesoPromedioPiezaKg)
+
+	detalle := formatScale(row.EscalaDetalle)
+	medioMayoreo := formatScale(row.EscalaMedioMayoreo)
+	tiers := []priceTier{
+		{Nombre: "Detalle", Emoji: "🏷", Precio: row.PrecioDetalle, Rango: "hasta " + detalle + " Kg"},
+		{Nombre: "Medio mayoreo", Emoji: "💰", Precio: row.PrecioMedioMayoreo, Rango: detalle + "-" + medioMayoreo + " Kg"},
+		{Nombre: "Mayoreo", Emoji: "💸", Precio: row.PrecioMayoreo, Rango: "más de " + medioMayoreo + " Kg"},
+	}
+	for _, tier := range tiers {
+		if tier.Precio > 0 {
+			card.Precios = append(card.Precios, tier)
+		}
+	}
+	return card
+}
+
+// Emojis for the products by keyword of their description, the first match wins
+var productEmojis = []struct {
+	keyword string
+	emoji   string
+}{
+	{"PAVO", "🦃"},
+	{"POLLO", "🐔"},
+	{"PECHUGA", "🐔"},
+	{"CERDO", "🐷"},
+	{"DE RES", "🐄"},
+	{"PESCADO", "🐟"},
+	{"CAMARON", "🦐"},
+	{"QUESO", "🧀"},
+	{"SALCHICHA", "🌭"},
+}
+
+func productEmoji(description string) string {
+	description = strings.ToUpper(description)
+	for _, entry := range productEmojis {
+		if strings.Contains(description, entry.keyword) {
+			return entry.emoji
+		}
+	}
+	return "🛒"
+}
+
+// formatScale writes a scale stored as text without trailing decimals, e.g. "30.000" as "30"
+func formatScale(scale string) string {
+	value, ok := parseFigure(scale)
+	if !ok {
+		return strings.TrimSpace(scale)
+	}
+	return formatFigure(value, "")
+}
+
+// anyFloat converts the untyped numeric columns returned by the driver
+func anyFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case []byte:
+		return parseFigure(string(v))
+	case string:
+		return parseFigure(v)
+	}
+	return 0, false
+}
+
+func parseCardTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(cardTemplateFuncs).Parse(text)
+}
+
+// loadCardTemplate reads a card template file of a profile
+func loadCardTemplate(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read card template: %w", err)
+	}
+	tmpl, err := parseCardTemplate(path, string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse card template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderProductCards executes the template of the profile with the cards of the rows
+func renderProductCards(tmpl *template.Template, rows []database.GetProductsInfoByCodeRow) (string, error) {
+	cards := make([]productCard, 0, len(rows))
+	for _, row := range rows {
+		cards = append(cards, newProductCard(row))
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, cards); err != nil {
+		return "", fmt.Errorf("failed to render product cards: %w", err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+type showProductsArgs struct {
+	ProductCodes []string `json:"productCodes" desc:"Códigos de los productos a mostrar, en el orden en que se deben mostrar."`
+	Mensaje      string   `json:"mensaje,omitempty" desc:"Mensaje breve opcional que se muestra antes de las tarjetas, por ejemplo una aclaración sobre la búsqueda."`
+}
+
+// showProducts fetches the products chosen by the model. The tool loop renders the
+// cards from this result and ends the request without another model round.
+func showProducts(ctx context.Context, queries *database.Queries, args showProductsArgs) (any, error) {
+	productCodes := args.ProductCodes
+	if len(productCodes) == 0 {
+		return nil, invalidArgumentError("productCodes debe incluir al menos un código")
+	}
+
+	rows, err := fetchProductsInfo(ctx, queries, productCodes)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, invalidArgumentError("no se encontró ningún producto con los códigos %s", strings.Join(productCodes, ", "))
+	}
+
+	// Keep the order chosen by the model
+	slices.SortStableFunc(rows, func(a, b database.GetProductsInfoByCodeRow) int {
+		return slices.Index(productCodes, a.Codigo) - slices.Index(productCodes, b.Codigo)
+	})
+	return rows, nil
+}
+
+// productCardsAnswer returns the optional message and the rendered cards when the model called mostrarProductos
+// in this turn. It returns false when the tool was not called or failed, so the loop goes on and the model sees the error.
+func productCardsAnswer(profile *AgentProfile, calls []ToolCall, results []ToolResult) (string, bool, error) {
+	for i, call := range calls {
+		if call.Name != showProductsTool {
+			continue
+		}
+		rows, ok := results[i].Response["result"].([]database.GetProductsInfoByCodeRow)
+		if !ok {
+			return "", false, nil
+		}
+
+		cards, err := renderProductCards(profile.Cards, rows)
+		if err != nil {
+			return "", false, err
+		}
+
+		// The call already passed validation when the tool ran
+		args, _ := decodeToolArgs[showProductsArgs](call.Args)
+		if message := strings.TrimSpace(args.Mensaje); message != "" {
+			cards = message + "\n\n" + cards
+		}
+		return cards, true, nil
+	}
+	return "", false, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"copo-ai-agent/internal/database"
+	"testing"
+)
+
+func TestProductCardTemplates(t *testing.T) {
+	var rows []database.GetProductsInfoByCodeRow
+	for _, p := range testCatalog {
+		rows = append(rows, database.GetProductsInfoByCodeRow{
+			Codigo:              p.Codigo,
+			Descripcion:         p.Descripcion,
+			Marca:               p.Marca,
+			ExistenciaKg:        p.ExistenciaKg,
+			PesoPromedioCajaKg:  p.PesoPromedioCajaKg,
+			PiezasPorCaja:       int32(p.PiezasPorCaja),
+			PesoPromedioPiezaKg: []byte("2.000"),
+			PrecioDetalle:       p.PrecioDetalle,
+			EscalaDetalle:       p.EscalaDetalle,
+			PrecioMedioMayoreo:  p.PrecioMedioMayoreo,
+			EscalaMedioMayoreo:  p.EscalaMedioMayoreo,
+			PrecioMayoreo:       p.PrecioMayoreo,
+		})
+	}
+	rows[1].ExistenciaKg = 0
+
+	for _, name := range []string{"gerencia", "almacen"} {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := loadCardTemplate("profiles/" + name + ".tmpl")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := renderProductCards(tmpl, rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, "cards_"+name, []byte(got))
+		})
+	}
+}
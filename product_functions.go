@@ -3,171 +3,109 @@ package main
 import (
 	"context"
 	"copo-ai-agent/internal/database"
-	"encoding/json"
-	"log"
 )
 
-func getCodesList(queries *database.Queries, args map[string]any) string {
-	productos, err := queries.GetAllProductCodes(context.Background())
+func getCodesList(ctx context.Context, queries *database.Queries, args noArgs) (any, error) {
+	productos, err := queries.GetAllProductCodes(ctx)
 	if err != nil {
-		log.Printf("failed to get products list: %v", err)
-		return "ocurrió un error al obtener la lista de códigos"
+		return nil, executionError("ocurrió un error al obtener la lista de códigos", err)
 	}
 
-	jsonData, err := json.Marshal(productos)
-	if err != nil {
-		log.Printf("failed to marshal results: %v", err)
-		return "ocurrió un error al obtener la lista de códigos"
-	}
+	return productos, nil
+}
 
-	return string(jsonData)
+type searchArgs struct {
+	SearchTerm string `json:"searchTerm" desc:"El término para realizar la busqueda. Debe ser una sola palabra en singular"`
 }
 
-func getProductInfoBySearchTerm(queries *database.Queries, args map[string]any) string {
-	arg := args["searchTerm"]
-	searchTerm, ok := arg.(string)
-	if !ok {
-		log.Println("failed to extract argument for term based search...")
-		return "ocurrió un problema al obtener la lista de códigos por búsqueda"
-	}
+func getProductInfoBySearchTerm(ctx context.Context, queries *database.Queries, args searchArgs) (any, error) {
 	codigos, err := queries.GetProductCodesBySearchTerm(
-		context.Background(),
-		database.GetProductCodesBySearchTermParams{SearchTerm: searchTerm},
+		ctx,
+		database.GetProductCodesBySearchTermParams{SearchTerm: args.SearchTerm},
 	)
 	if err != nil {
-		log.Printf("failed to get products list by search term: %v", err)
-		return "ocurrió un problema al obtener la lista de códigos por búsqueda"
+		return nil, executionError("ocurrió un problema al obtener la lista de códigos por búsqueda", err)
 	}
 
 	var codigosString []string
 	for _, c := range codigos {
 		codigosString = append(codigosString, c.Codigo)
 	}
-	mapCodigos := map[string]any{
-		"productCodes": codigosString,
-	}
-
-	info := getProductsInfo(queries, mapCodigos)
 
-	jsonData, err := json.Marshal(info)
-	if err != nil {
-		log.Printf("failed to marshal products list by search: %v", err)
-		return "ocurrió un problema al obtener la lista de códigos por búsqueda"
-	}
+	return fetchProductsInfo(ctx, queries, codigosString)
+}
 
-	return string(jsonData)
+type brandArgs struct {
+	Brand string `json:"brand" desc:"La marca para realizar la busqueda"`
 }
 
-func getProductInfoByBrand(queries *database.Queries, args map[string]any) string {
-	brandArg := args["brand"]
-	brand, ok := brandArg.(string)
-	if !ok {
-		log.Println("failed to extract argument for brand based search...")
-		return "ocurrió un problema al obtener la lista de códigos por brand"
-	}
+func getProductInfoByBrand(ctx context.Context, queries *database.Queries, args brandArgs) (any, error) {
 	codigos, err := queries.GetProductCodesByBrand(
-		context.Background(),
-		brand,
+		ctx,
+		args.Brand,
 	)
 	if err != nil {
-		log.Printf("failed to get products list by brand: %v", err)
-		return "ocurrió un problema al obtener la lista de códigos por marca"
+		return nil, executionError("ocurrió un problema al obtener la lista de códigos por marca", err)
 	}
 
 	var codigosString []string
 	for _, c := range codigos {
 		codigosString = append(codigosString, c.Codigo)
 	}
-	mapCodigos := map[string]any{
-		"productCodes": codigosString,
-	}
-
-	info := getProductsInfo(queries, mapCodigos)
-
-	jsonData, err := json.Marshal(info)
-	if err != nil {
-		log.Printf("failed to marshal products list by brand: %v", err)
-		return "ocurrió un problema al obtener la lista de códigos por marca"
-	}
 
-	return string(jsonData)
+	return fetchProductsInfo(ctx, queries, codigosString)
 }
 
-func getProductInfoByCategories(queries *database.Queries, args map[string]any) string {
-	lineaArg := args["linea"]
-	sublineaArg := args["sublinea"]
-	linea, ok := lineaArg.(string)
-	if !ok {
-		log.Println("failed to extract argument for linea based search...")
-		return "ocurrió un problema al obtener la lista de códigos por linea"
-	}
-	sublinea, ok := sublineaArg.(string)
-	if !ok {
-		log.Println("failed to extract argument for sublinea based search...")
-		return "ocurrió un problema al obtener la lista de códigos por sbulinea"
-	}
+type categoryArgs struct {
+	Linea    string `json:"linea" desc:"La linea para realizar la busqueda, si se quieren buscar todas la lineas debe ser un texto vacio ''"`
+	Sublinea string `json:"sublinea" desc:"La linea para realizar la busqueda, si se quieren buscar todas la sublineas debe ser un texto vacio ''"`
+}
 
+func getProductInfoByCategories(ctx context.Context, queries *database.Queries, args categoryArgs) (any, error) {
 	codigos, err := queries.GetProductCodesByCategory(
-		context.Background(),
+		ctx,
 		database.GetProductCodesByCategoryParams{
-			Linea:    linea,
-			Sublinea: sublinea,
+			Linea:    args.Linea,
+			Sublinea: args.Sublinea,
 		},
 	)
 	if err != nil {
-		log.Printf("failed to get products list by category: %v", err)
-		return "ocurrió un problema al obtener la lista de códigos por linea"
+		return nil, executionError("ocurrió un problema al obtener la lista de códigos por linea", err)
 	}
 
 	var codigosString []string
 	for _, c := range codigos {
 		codigosString = append(codigosString, c.Codigo)
 	}
-	mapCodigos := map[string]any{
-		"productCodes": codigosString,
-	}
-
-	info := getProductsInfo(queries, mapCodigos)
 
-	jsonData, err := json.Marshal(info)
-	if err != nil {
-		log.Printf("failed to marshal products list by category: %v", err)
-		return "ocurrió un problema al obtener la lista de códigos por linea"
-	}
+	return fetchProductsInfo(ctx, queries, codigosString)
+}
 
-	return string(jsonData)
+type productCodesArgs struct {
+	ProductCodes []string `json:"productCodes" desc:"Lista de códigos de productos (strings)."`
 }
 
-func getProductsInfo(queries *database.Queries, args map[string]any) string {
-	var productCodes []string
-	if argCodes, ok := args["productCodes"]; ok {
-		if codeSlice, ok := argCodes.([]string); ok {
-			productCodes = codeSlice
-		} else if codesSlice, ok := argCodes.([]any); ok {
-			log.Println("argument is of type []any")
-			for _, v := range codesSlice {
-				if str, ok := v.(string); ok {
-					log.Println(str)
-					productCodes = append(productCodes, str)
-				}
-			}
-		}
-	} else {
-		log.Println("failed to extract argument for codigos...")
-		return "ocurrió un problema al obtener información de los códigos"
-	}
+func getProductsInfo(ctx context.Context, queries *database.Queries, args productCodesArgs) (any, error) {
+	return fetchProductsInfo(ctx, queries, args.ProductCodes)
+}
 
-	infoProductos, err := queries.GetProductsInfoByCode(context.Background(), productCodes)
+// fetchProductsInfo returns the detailed information of the given product codes. ExistenciaKg is
+// the stock that can be promised: Vexiact minus the active reservations.
+func fetchProductsInfo(ctx context.Context, queries *database.Queries, productCodes []string) ([]database.GetProductsInfoByCodeRow, error) {
+	infoProductos, err := queries.GetProductsInfoByCode(ctx, productCodes)
 	if err != nil {
-		log.Printf("failed to get products info: %v", err)
-		return "ocurrió un error al obtener la información de los productos"
+		return nil, executionError("ocurrió un error al obtener la información de los productos", err)
+	}
+	if len(infoProductos) == 0 {
+		return infoProductos, nil
 	}
 
-	jsonData, err := json.Marshal(infoProductos)
+	holds, err := activeHolds(ctx, queries, productCodes)
 	if err != nil {
-		log.Printf("failed to marshal results: %v", err)
-		return "ocurrió un error al obtener la información de los productos"
+		return nil, err
 	}
-
-	return string(jsonData)
+	for i := range infoProductos {
+		infoProductos[i].ExistenciaKg = availableToPromise(infoProductos[i].ExistenciaKg, holds[infoProductos[i].Codigo])
+	}
+	return infoProductos, nil
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"database/sql"
+	"time"
+)
+
+// queryTimeoutDB is a database.DBTX that limits every query to its own deadline, so a tool that
+// runs several queries is not held to a single deadline for all of them
+type queryTimeoutDB struct {
+	db      database.DBTX
+	timeout time.Duration
+}
+
+func (d queryTimeoutDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	return d.db.ExecContext(ctx, query, args...)
+}
+
+func (d queryTimeoutDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	return d.db.PrepareContext(ctx, query)
+}
+
+func (d queryTimeoutDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.QueryContext(d.rowsContext(ctx), query, args...)
+}
+
+func (d queryTimeoutDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRowContext(d.rowsContext(ctx), query, args...)
+}
+
+// rowsContext returns the context of a query whose rows are read after it returns. The deadline
+// covers the reading too, and the context is released when it passes or the caller's context ends.
+func (d queryTimeoutDB) rowsContext(ctx context.Context) context.Context {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	context.AfterFunc(ctx, cancel)
+	return ctx
+}
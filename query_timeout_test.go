@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slowDB answers every query after delay, or fails when the context ends first
+type slowDB struct {
+	delay time.Duration
+}
+
+func (d slowDB) wait(ctx context.Context) error {
+	select {
+	case <-time.After(d.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d slowDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, d.wait(ctx)
+}
+
+func (d slowDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, d.wait(ctx)
+}
+
+func (d slowDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, d.wait(ctx)
+}
+
+func (d slowDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	d.wait(ctx)
+	return nil
+}
+
+// The deadline applies to each query, not to all the queries of a tool together
+func TestQueryTimeoutPerQuery(t *testing.T) {
+	db := queryTimeoutDB{db: slowDB{delay: 30 * time.Millisecond}, timeout: 200 * time.Millisecond}
+	for i := range 8 {
+		if _, err := db.QueryContext(context.Background(), "-- name: GetProductStockLevels"); err != nil {
+			t.Fatalf("query %d failed: %v", i+1, err)
+		}
+	}
+
+	db.db = slowDB{delay: time.Second}
+	if _, err := db.ExecContext(context.Background(), "-- name: CreateQuote"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the slow exec to hit its deadline", err)
+	}
+	if _, err := db.QueryContext(context.Background(), "-- name: GetQuote"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want the slow query to hit its deadline", err)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"math"
+	"strings"
+)
+
+// Units accepted by cotizar, converted to kg with the average weights of the product
+const (
+	unitKg     = "kg"
+	unitBoxes  = "cajas"
+	unitPieces = "piezas"
+)
+
+// Price lists of the Grupo tiers, from detalle (fac1) to mayoreo (fac3), and of the prices set by the rep
+const (
+	priceListRetail    = "detalle"
+	priceListHalfSale  = "medio mayoreo"
+	priceListWholesale = "mayoreo"
+	priceListSpecial   = "precio especial"
+)
+
+type quoteItemArgs struct {
+	Codigo   string  `json:"codigo" desc:"Código del producto."`
+	Cantidad float64 `json:"cantidad" desc:"Cantidad pedida en la unidad indicada."`
+	Unidad   string  `json:"unidad" enum:"kg,cajas,piezas" desc:"Unidad de la cantidad: kg, cajas o piezas."`
+	PrecioKg float64 `json:"precioKg,omitempty" desc:"Precio especial por kg pedido por el vendedor. Si no se indica se usa el precio de la escala que corresponde."`
+}
+
+type quoteArgs struct {
+	Productos []quoteItemArgs `json:"productos" desc:"Productos del pedido con su cantidad."`
+}
+
+// quoteLine is a product of the quote. The tier prices and scales are included so the
+// rep can tell the customer how much more is needed for the next tier.
+type quoteLine struct {
+	Codigo      string
+	Descripcion string
+	Cantidad    float64
+	Unidad      string
+	Kg          float64
+	// Price list applied by the quantity in kg
+	Lista              string
+	PrecioKg           float64
+	Importe            float64
+	TasaIva            float64
+	Iva                float64
+	PrecioDetalle      float64
+	EscalaDetalle      string
+	PrecioMedioMayoreo float64
+	EscalaMedioMayoreo string
+	PrecioMayoreo      float64
+}
+
+type quote struct {
+	Lineas   []quoteLine
+	Subtotal float64
+	Iva      float64
+	Total    float64
+	// Prices out of the pricing policy, a saved quote with any of them waits for a manager
+	FueraDePolitica    []policyViolation
+	RequiereAprobacion bool
+	// Products whose stock does not cover the quote or would be left below the minimum
+	AvisosExistencia []stockWarning
+}
+
+// quoteProducts prices a mixed order. Quantities in boxes or pieces are converted to kg,
+// the tier of every line is chosen by its kg and IVA is applied with the rate of the product.
+// Every price, from the tiers or special, is checked against the pricing policy.
+func quoteProducts(policy pricingPolicy) func(context.Context, *database.Queries, quoteArgs) (any, error) {
+	return func(ctx context.Context, queries *database.Queries, args quoteArgs) (any, error) {
+		return buildQuote(ctx, queries, policy, args.Productos)
+	}
+}
+
+func buildQuote(ctx context.Context, queries *database.Queries, policy pricingPolicy, items []quoteItemArgs) (quote, error) {
+	if len(items) == 0 {
+		return quote{}, invalidArgumentError("productos debe incluir al menos un producto")
+	}
+
+	var codes []string
+	for _, item := range items {
+		codes = append(codes, item.Codigo)
+	}
+	rows, err := queries.GetQuoteProducts(ctx, codes)
+	if err != nil {
+		return quote{}, executionError("ocurrió un error al obtener los precios de los productos", err)
+	}
+	products := map[string]database.GetQuoteProductsRow{}
+	for _, row := range rows {
+		products[row.Codigo] = row
+	}
+
+	var q quote
+	var missing []string
+	for _, item := range items {
+		product, ok := products[item.Codigo]
+		if !ok {
+			missing = append(missing, item.Codigo)
+			continue
+		}
+		line, err := newQuoteLine(product, item)
+		if err != nil {
+			return quote{}, err
+		}
+		q.Lineas = append(q.Lineas, line)
+		q.Subtotal += line.Importe
+		q.Iva += line.Iva
+	}
+	if len(missing) > 0 {
+		return quote{}, invalidArgumentError("no se encontraron los códigos %s", strings.Join(missing, ", "))
+	}
+
+	q.Subtotal = roundCents(q.Subtotal)
+	q.Iva = roundCents(q.Iva)
+	q.Total = roundCents(q.Subtotal + q.Iva)
+
+	var prices []policyPrice
+	for _, line := range q.Lineas {
+		prices = append(prices, policyPrice{Codigo: line.Codigo, Precio: line.PrecioKg})
+	}
+	q.FueraDePolitica, err = policy.check(ctx, queries, prices)
+	if err != nil {
+		return quote{}, err
+	}
+	q.RequiereAprobacion = len(q.FueraDePolitica) > 0
+
+	q.AvisosExistencia, err = stockWarnings(ctx, queries, q.Lineas)
+	if err != nil {
+		return quote{}, err
+	}
+	return q, nil
+}
+
+func newQuoteLine(product database.GetQuoteProductsRow, item quoteItemArgs) (quoteLine, error) {
+	if item.Cantidad <= 0 {
+		return quoteLine{}, invalidArgumentError("la cantidad del producto %s debe ser mayor a cero", product.Codigo)
+	}
+
+	kg, err := quantityKg(product, item.Cantidad, item.Unidad)
+	if err != nil {
+		return quoteLine{}, err
+	}
+
+	list, price := tierPrice(product, kg)
+	if item.PrecioKg < 0 {
+		return quoteLine{}, invalidArgumentError("el precio especial del producto %s debe ser mayor a cero", product.Codigo)
+	}
+	if item.PrecioKg > 0 {
+		list, price = priceListSpecial, item.PrecioKg
+	}
+	if price <= 0 {
+		return quoteLine{}, invalidArgumentError("el producto %s no tiene precio registrado", product.Codigo)
+	}
+
+	line := quoteLine{
+		Codigo:             product.Codigo,
+		Descripcion:        product.Descripcion,
+		Cantidad:           item.Cantidad,
+		Unidad:             item.Unidad,
+		Kg:                 kg,
+		Lista:              list,
+		PrecioKg:           price,
+		Importe:            roundCents(kg * price),
+		TasaIva:            product.TasaIva,
+		PrecioDetalle:      product.PrecioDetalle,
+		EscalaDetalle:      product.EscalaDetalle,
+		PrecioMedioMayoreo: product.PrecioMedioMayoreo,
+		EscalaMedioMayoreo: product.EscalaMedioMayoreo,
+		PrecioMayoreo:      product.PrecioMayoreo,
+	}
+	// Vivaart is the IVA rate in percent
+	line.Iva = roundCents(line.Importe * product.TasaIva / 100)
+	return line, nil
+}
+
+// quantityKg converts a quantity to kg, rounded to two decimals as it is weighed
+func quantityKg(product database.GetQuoteProductsRow, quantity float64, unit string) (float64, error) {
+	switch unit {
+	case unitKg:
+		return roundCents(quantity), nil
+	case unitBoxes:
+		if product.PesoPromedioCajaKg <= 0 {
+			return 0, invalidArgumentError("el producto %s no tiene peso promedio por caja, cotízalo en kg", product.Codigo)
+		}
+		return roundCents(quantity * product.PesoPromedioCajaKg), nil
+	case unitPieces:
+		if product.PesoPromedioCajaKg <= 0 || product.PiezasPorCaja <= 0 {
+			return 0, invalidArgumentError("el producto %s no tiene peso promedio por pieza, cotízalo en kg", product.Codigo)
+		}
+		return roundCents(quantity * product.PesoPromedioCajaKg / float64(product.PiezasPorCaja)), nil
+	}
+	return 0, invalidArgumentError("unidad desconocida %q", unit)
+}
+
+// tierPrice picks the Grupo tier for a quantity: detalle up to EscalaDetalle kg, medio mayoreo
+// up to EscalaMedioMayoreo kg and mayoreo above it. A tier without price falls back to the previous one.
+func tierPrice(product database.GetQuoteProductsRow, kg float64) (string, float64) {
+	tiers := []struct {
+		list  string
+		price float64
+	}{
+		{priceListRetail, product.PrecioDetalle},
+		{priceListHalfSale, product.PrecioMedioMayoreo},
+		{priceListWholesale, product.PrecioMayoreo},
+	}
+
+	tier := 2
+	if limit, ok := parseFigure(product.EscalaDetalle); !ok || kg <= limit {
+		tier = 0
+	} else if limit, ok := parseFigure(product.EscalaMedioMayoreo); !ok || kg <= limit {
+		tier = 1
+	}
+	for tier > 0 && tiers[tier].price <= 0 {
+		tier--
+	}
+	return tiers[tier].list, tiers[tier].price
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
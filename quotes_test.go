@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"errors"
+	"testing"
+)
+
+var testQuoteProduct = database.GetQuoteProductsRow{
+	Codigo:             "101",
+	Descripcion:        "PECHUGA DE POLLO",
+	PesoPromedioCajaKg: 20,
+	PiezasPorCaja:      8,
+	PrecioDetalle:      95.5,
+	EscalaDetalle:      "30",
+	PrecioMedioMayoreo: 92,
+	EscalaMedioMayoreo: "100",
+	PrecioMayoreo:      89,
+}
+
+func TestTierPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(p *database.GetQuoteProductsRow)
+		kg        float64
+		wantList  string
+		wantPrice float64
+	}{
+		{"up to the detalle scale", nil, 30, priceListRetail, 95.5},
+		{"above the detalle scale", nil, 30.01, priceListHalfSale, 92},
+		{"up to the medio mayoreo scale", nil, 100, priceListHalfSale, 92},
+		{"above the medio mayoreo scale", nil, 100.01, priceListWholesale, 89},
+		{"detalle scale that can not be read", func(p *database.GetQuoteProductsRow) { p.EscalaDetalle = "s/e" }, 500, priceListRetail, 95.5},
+		{"medio mayoreo scale that can not be read", func(p *database.GetQuoteProductsRow) { p.EscalaMedioMayoreo = "" }, 500, priceListHalfSale, 92},
+		{"scale with thousands separator", func(p *database.GetQuoteProductsRow) { p.EscalaMedioMayoreo = "1,000" }, 500, priceListHalfSale, 92},
+		{"mayoreo without price", func(p *database.GetQuoteProductsRow) { p.PrecioMayoreo = 0 }, 500, priceListHalfSale, 92},
+		{"mayoreo and medio mayoreo without price", func(p *database.GetQuoteProductsRow) {
+			p.PrecioMedioMayoreo, p.PrecioMayoreo = 0, 0
+		}, 500, priceListRetail, 95.5},
+		{"no price at all", func(p *database.GetQuoteProductsRow) {
+			p.PrecioDetalle, p.PrecioMedioMayoreo, p.PrecioMayoreo = 0, 0, 0
+		}, 10, priceListRetail, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := testQuoteProduct
+			if tt.change != nil {
+				tt.change(&product)
+			}
+			list, price := tierPrice(product, tt.kg)
+			if list != tt.wantList || price != tt.wantPrice {
+				t.Errorf("tierPrice(%v) = %s %v, want %s %v", tt.kg, list, price, tt.wantList, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestQuantityKg(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(p *database.GetQuoteProductsRow)
+		quantity float64
+		unit     string
+		want     float64
+		wantErr  string
+	}{
+		{"kg rounded as weighed", nil, 12.345, unitKg, 12.35, ""},
+		{"boxes", nil, 3, unitBoxes, 60, ""},
+		{"pieces", nil, 3, unitPieces, 7.5, ""},
+		{"boxes without weight", func(p *database.GetQuoteProductsRow) { p.PesoPromedioCajaKg = 0 }, 3, unitBoxes, 0,
+			"el producto 101 no tiene peso promedio por caja, cotízalo en kg"},
+		{"pieces without pieces per box", func(p *database.GetQuoteProductsRow) { p.PiezasPorCaja = 0 }, 3, unitPieces, 0,
+			"el producto 101 no tiene peso promedio por pieza, cotízalo en kg"},
+		{"unknown unit", nil, 3, "litros", 0, `unidad desconocida "litros"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := testQuoteProduct
+			if tt.change != nil {
+				tt.change(&product)
+			}
+			kg, err := quantityKg(product, tt.quantity, tt.unit)
+			if tt.wantErr != "" {
+				var toolErr *ToolError
+				if !errors.As(err, &toolErr) || toolErr.Code != toolErrInvalidArgument || toolErr.Message != tt.wantErr {
+					t.Errorf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || kg != tt.want {
+				t.Errorf("quantityKg(%v %s) = %v, %v, want %v", tt.quantity, tt.unit, kg, err, tt.want)
+			}
+		})
+	}
+}
+
+// A code ordered twice gets a line per item, each priced by its own kg, and the stock
+// warning adds up the kg of both lines
+func TestQuoteRepeatedCode(t *testing.T) {
+	fixClock(t, testNow)
+	db := newFakeDB(testCatalog)
+	t.Cleanup(func() { db.Close() })
+
+	q, err := buildQuote(context.Background(), database.New(db), pricingPolicy{}, []quoteItemArgs{
+		{Codigo: "101", Cantidad: 20, Unidad: unitKg},
+		{Codigo: "101", Cantidad: 110, Unidad: unitKg},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Lineas) != 2 {
+		t.Fatalf("got %d lines, want 2", len(q.Lineas))
+	}
+	if q.Lineas[0].Lista != priceListRetail || q.Lineas[1].Lista != priceListWholesale {
+		t.Errorf("lines priced at %s and %s, want %s and %s", q.Lineas[0].Lista, q.Lineas[1].Lista, priceListRetail, priceListWholesale)
+	}
+	if want := roundCents(20*95.5 + 110*89); q.Subtotal != want {
+		t.Errorf("subtotal = %v, want %v", q.Subtotal, want)
+	}
+	if len(q.AvisosExistencia) != 1 || q.AvisosExistencia[0].KgCotizados != 130 {
+		t.Errorf("stock warnings = %+v, want one for 130 kg", q.AvisosExistencia)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// exchangeRecord is the full exchange of a request: the conversation received,
+// every tool call with its result and the final text of the model
+type exchangeRecord struct {
+	Time         time.Time      `json:"time"`
+	User         string         `json:"user"`
+	Role         string         `json:"role,omitempty"`
+	Profile      string         `json:"profile"`
+	Model        string         `json:"model"`
+	Conversation conversation   `json:"conversation"`
+	ToolCalls    []toolExchange `json:"tool_calls"`
+	FinalText    string         `json:"final_text"`
+	Issues       []answerIssue  `json:"issues,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Usage        tokenUsage     `json:"usage"`
+}
+
+// exchangeRecorder appends every exchange to a JSONL file when recording is enabled
+type exchangeRecorder struct {
+	mu   sync.Mutex
+	path string
+}
+
+// newExchangeRecorder returns nil when path is empty, recording is then disabled
+func newExchangeRecorder(path string) *exchangeRecorder {
+	if path == "" {
+		return nil
+	}
+	return &exchangeRecorder{path: path}
+}
+
+func (r *exchangeRecorder) record(identity Identity, profile *AgentProfile, conv conversation, result queryResult, err error) {
+	if r == nil {
+		return
+	}
+
+	record := exchangeRecord{
+		Time:         time.Now(),
+		User:         identity.User,
+		Role:         identity.Role,
+		Profile:      profile.ID,
+		Model:        profile.Model,
+		Conversation: conv,
+		ToolCalls:    result.ToolCalls,
+		FinalText:    result.Text,
+		Issues:       result.Issues,
+		Usage:        result.Usage,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	file, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("failed to open recordings file: %v\n", err)
+		return
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(record); err != nil {
+		log.Printf("failed to write recording: %v\n", err)
+	}
+}
+
+// readExchangeRecords loads every exchange saved in a recordings file
+func readExchangeRecords(path string) ([]exchangeRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recordings file: %w", err)
+	}
+	defer file.Close()
+
+	var records []exchangeRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record exchangeRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid recording at line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recordings file: %w", err)
+	}
+	return records, nil
+}
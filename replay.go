@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// runReplay re-runs the user queries of a recordings file against the current prompt
+// and model, and reports what changed in the tools called, the product codes mentioned
+// and the prices quoted. Usage: go run . replay -in recordings.jsonl [-profile ID] [-model M]
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	in := flags.String("in", "", "recordings file (JSONL) to replay")
+	profileID := flags.String("profile", "", "profile to replay with, defaults to the recorded profile")
+	model := flags.String("model", "", "model to replay with, defaults to the model of the profile")
+	profilesFile := flags.String("profiles", "", "profiles config file, defaults to PROFILES_FILE")
+	flags.Parse(args)
+
+	if *in == "" {
+		return fmt.Errorf("missing -in recordings file")
+	}
+	records, err := readExchangeRecords(*in)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *profilesFile != "" {
+		cfg.ProfilesFile = *profilesFile
+	}
+	// Replaying must not add new recordings to the file being read
+	cfg.RecordingsPath = ""
+
+	ctx := context.Background()
+	app, err := newApp(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	flagged := 0
+	for i, record := range records {
+		if record.Error != "" {
+			fmt.Printf("[%d] skipped, the recorded request failed: %s\n", i+1, record.Error)
+			continue
+		}
+
+		id := record.Profile
+		if *profileID != "" {
+			id = *profileID
+		}
+		profile, ok := app.profiles.get(id)
+		if !ok {
+			return fmt.Errorf("unknown profile %q", id)
+		}
+		if *model != "" {
+			override := *profile
+			override.Model = *model
+			profile = &override
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, cfg.RequestTimeout)
+		result, err := app.replayQuery(queryCtx, profile, record)
+		cancel()
+		if err != nil {
+			fmt.Printf("[%d] %q failed: %v\n", i+1, record.Conversation.UserQuery, err)
+			flagged++
+			continue
+		}
+
+		if writeReplayDiff(os.Stdout, i+1, record, result) {
+			flagged++
+		}
+	}
+
+	fmt.Printf("\n%d recordings replayed, %d flagged\n", len(records), flagged)
+	if flagged > 0 {
+		return fmt.Errorf("%d replayed answers quote figures that do not match the tools", flagged)
+	}
+	return nil
+}
+
+// replayQuery runs a recorded query again. Replays run against the live database, so the
+// tools that write are swapped for a stub and no quote or reservation is stored.
+func (app *App) replayQuery(ctx context.Context, profile *AgentProfile, record exchangeRecord) (queryResult, error) {
+	replayed := *profile
+	replayed.Tools = replayTools(profile.Tools)
+	return app.processUserQuery(ctx, &replayed, Identity{User: "replay", Role: record.Role}, record.Conversation, nil)
+}
+
+// replayStub is the result of a tool that writes during a replay
+type replayStub struct {
+	Mensaje string
+}
+
+// replayTools returns the tools with the ones that write replaced by a stub that only reports the call
+func replayTools(tools CompletionTools) CompletionTools {
+	var replayed CompletionTools
+	for _, tool := range tools.Tools {
+		if tool.Writes {
+			tool.Function = func(ctx context.Context, queries *database.Queries, args map[string]any) (any, error) {
+				return replayStub{Mensaje: "repetición de una conversación grabada, no se guardó nada"}, nil
+			}
+		}
+		replayed.Tools = append(replayed.Tools, tool)
+	}
+	return replayed
+}
+
+// writeReplayDiff prints the differences between the recorded and the replayed answer.
+// It returns true when the replayed answer quotes prices or stock the tools did not return.
+func writeReplayDiff(w io.Writer, n int, record exchangeRecord, result queryResult) bool {
+	oldFacts := extractAnswerFacts(record.FinalText)
+	newFacts := extractAnswerFacts(result.Text)
+
+	fmt.Fprintf(w, "[%d] %s: %q\n", n, record.User, record.Conversation.UserQuery)
+
+	changed := false
+	changed = writeSetDiff(w, "tools", toolNames(record.ToolCalls), toolNames(result.ToolCalls)) || changed
+	changed = writeSetDiff(w, "codes", oldFacts.Codes, newFacts.Codes) || changed
+	changed = writeSetDiff(w, "prices", figureKeys(oldFacts.Prices), figureKeys(newFacts.Prices)) || changed
+	changed = writeSetDiff(w, "stock", figureKeys(oldFacts.Stock), figureKeys(newFacts.Stock)) || changed
+	if !changed {
+		fmt.Fprintln(w, "    no changes")
+	}
+
+	prices, stock := unverifiedFigures(newFacts, collectToolFacts(result.ToolCalls))
+	if len(prices) > 0 {
+		fmt.Fprintf(w, "    ⚠ unverified prices: %s\n", strings.Join(figureKeys(prices), ", "))
+	}
+	if len(stock) > 0 {
+		fmt.Fprintf(w, "    ⚠ unverified stock: %s\n", strings.Join(figureKeys(stock), ", "))
+	}
+	return len(prices) > 0 || len(stock) > 0
+}
+
+// writeSetDiff prints the values removed (-) and added (+) between old and new, returning whether they differ
+func writeSetDiff(w io.Writer, label string, old, new []string) bool {
+	oldSet := map[string]bool{}
+	for _, value := range old {
+		oldSet[value] = true
+	}
+	newSet := map[string]bool{}
+	for _, value := range new {
+		newSet[value] = true
+	}
+
+	var changes []string
+	for _, value := range uniqueStrings(old) {
+		if !newSet[value] {
+			changes = append(changes, "-"+value)
+		}
+	}
+	for _, value := range uniqueStrings(new) {
+		if !oldSet[value] {
+			changes = append(changes, "+"+value)
+		}
+	}
+	if len(changes) == 0 {
+		return false
+	}
+	fmt.Fprintf(w, "    %-6s %s\n", label, strings.Join(changes, " "))
+	return true
+}
+
+func toolNames(exchanges []toolExchange) []string {
+	var names []string
+	for _, exchange := range exchanges {
+		names = append(names, exchange.Name)
+	}
+	return uniqueStrings(names)
+}
+
+func figureKeys(values []float64) []string {
+	var keys []string
+	for _, value := range values {
+		keys = append(keys, figureKey(value))
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// A replayed reservation runs the stub and leaves ai_stock_reservations untouched
+func TestReplayDoesNotWrite(t *testing.T) {
+	fixClock(t, testNow)
+
+	provider := newFakeProvider(
+		scriptedTurn{ToolCalls: []ToolCall{{ID: "call-1", Name: "apartarExistencia", Args: map[string]any{
+			"codigo": "101", "kg": 80.0, "cliente": "Carnicería Don Pepe",
+		}}}},
+		scriptedTurn{Text: "Listo, aparté 80 kg."},
+	)
+	app := newTestApp(t, provider)
+	profile, _ := app.profiles.get("COPO-AI")
+	record := exchangeRecord{User: "mostrador", Role: roleSales, Profile: "COPO-AI", Conversation: conversation{UserQuery: "aparta 80 kg de pechuga"}}
+
+	result, err := app.replayQuery(context.Background(), profile, record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.ToolCalls) != 1 || result.ToolCalls[0].Response["result"] == nil {
+		t.Fatalf("unexpected tool calls %+v", result.ToolCalls)
+	}
+	if _, ok := result.ToolCalls[0].Response["result"].(replayStub); !ok {
+		t.Errorf("apartarExistencia returned %+v, want the replay stub", result.ToolCalls[0].Response)
+	}
+
+	if _, err := app.queries.GetStockReservation(context.Background(), 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("the replay stored a reservation: %v", err)
+	}
+	holds, err := app.queries.GetActiveStockHolds(context.Background(), database.GetActiveStockHoldsParams{Ahora: testNow, ProductCodes: []string{"101"}})
+	if err != nil || len(holds) != 0 {
+		t.Errorf("got holds %+v, %v, want none", holds, err)
+	}
+}
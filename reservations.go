@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// Status of a reservation as told to the model
+const (
+	reservationActive   = "activa"
+	reservationReleased = "liberada"
+)
+
+type reserveStockArgs struct {
+	Codigo        string  `json:"codigo" desc:"Código del producto."`
+	Kg            float64 `json:"kg" desc:"Kg que se apartan para el cliente."`
+	Cliente       string  `json:"cliente" desc:"Nombre del cliente para el que se aparta la existencia."`
+	NumeroCliente string  `json:"numeroCliente,omitempty" desc:"Número de cliente obtenido con buscarCliente, si se conoce."`
+	Horas         float64 `json:"horas,omitempty" desc:"Horas que dura la reserva. Si no se indica se usa la duración normal."`
+}
+
+type reservationArgs struct {
+	Reserva int64 `json:"reserva" desc:"Número de la reserva."`
+}
+
+type extendReservationArgs struct {
+	Reserva int64   `json:"reserva" desc:"Número de la reserva."`
+	Horas   float64 `json:"horas,omitempty" desc:"Horas que dura la reserva a partir de ahora. Si no se indica se usa la duración normal."`
+}
+
+// stockReservation is a hold of stock for a customer
+type stockReservation struct {
+	Reserva       int64
+	Codigo        string
+	Descripcion   string
+	Kg            float64
+	Cliente       string
+	NumeroCliente string
+	Vence         string
+	Estado        string
+	// Stock of the product that can still be promised, Vexiact minus the active holds
+	DisponibleKg float64
+}
+
+// reserveStockTool returns the apartarExistencia tool. A reservation lasts the configured
+// time unless the model asks for other hours, never longer than the configured maximum.
+func reserveStockTool(cfg Config) FunctionTool {
+	tool := newFunctionTool("apartarExistencia",
+		"Aparta kg de un producto para un cliente durante unas horas, para que otros vendedores no los prometan. "+
+			"Devuelve el número de reserva, su vencimiento y la existencia que queda disponible.",
+		func(ctx context.Context, queries *database.Queries, args reserveStockArgs) (any, error) {
+			return reserveStock(ctx, queries, cfg, args)
+		})
+	tool.Writes = true
+	return tool
+}
+
+func extendReservationTool(cfg Config) FunctionTool {
+	tool := newFunctionTool("extenderReserva",
+		"Extiende el vencimiento de una reserva activa de existencia. Las reservas vencidas o liberadas no se pueden extender.",
+		func(ctx context.Context, queries *database.Queries, args extendReservationArgs) (any, error) {
+			return extendReservation(ctx, queries, cfg, args)
+		})
+	tool.Writes = true
+	return tool
+}
+
+func releaseReservationTool() FunctionTool {
+	tool := newFunctionTool("liberarReserva",
+		"Libera una reserva de existencia cuando el cliente ya no la necesita, para que la puedan prometer otros vendedores.",
+		releaseReservation)
+	tool.Writes = true
+	return tool
+}
+
+func reserveStock(ctx context.Context, queries *database.Queries, cfg Config, args reserveStockArgs) (any, error) {
+	code := strings.TrimSpace(args.Codigo)
+	cliente := strings.TrimSpace(args.Cliente)
+	if code == "" {
+		return nil, invalidArgumentError("codigo no puede estar vacío")
+	}
+	if cliente == "" {
+		return nil, invalidArgumentError("falta el nombre del cliente")
+	}
+	if args.Kg <= 0 {
+		return nil, invalidArgumentError("kg debe ser mayor a cero")
+	}
+	ttl, err := reservationTTL(cfg, args.Horas)
+	if err != nil {
+		return nil, err
+	}
+
+	levels, err := queries.GetProductStockLevels(ctx, []string{code})
+	if err != nil {
+		return nil, executionError("ocurrió un error al revisar la existencia", err)
+	}
+	if len(levels) == 0 {
+		return nil, invalidArgumentError("no se encontró el código %s", code)
+	}
+	product := levels[0]
+	available, err := availableStock(ctx, queries, product)
+	if err != nil {
+		return nil, err
+	}
+	kg := roundCents(args.Kg)
+	if kg > available {
+		return nil, invalidArgumentError("solo hay %.2f kg disponibles de %s para apartar", max(available, 0), code)
+	}
+
+	// Another rep may reserve the same stock between the check and the insert. The insert locks
+	// the product row and only stores the reservation when the stock not held still covers it,
+	// so the reservations of a product are made one at a time.
+	identity, _ := identityFromContext(ctx)
+	created := timeNow().Truncate(time.Second)
+	result, err := queries.CreateStockReservation(ctx, database.CreateStockReservationParams{
+		Kg:            kg,
+		Cliente:       cliente,
+		NumeroCliente: strings.TrimSpace(args.NumeroCliente),
+		Usuario:       identity.User,
+		Sucursal:      identity.Branch,
+		Creada:        created,
+		Vence:         created.Add(ttl),
+		Codigo:        product.Codigo,
+	})
+	var inserted, id int64
+	if err == nil {
+		inserted, err = result.RowsAffected()
+	}
+	if err == nil && inserted > 0 {
+		id, err = result.LastInsertId()
+	}
+	if err != nil {
+		return nil, executionError("ocurrió un error al apartar la existencia", err)
+	}
+
+	available, err = availableStock(ctx, queries, product)
+	if err != nil {
+		return nil, err
+	}
+	if inserted == 0 {
+		return nil, invalidArgumentError("otro vendedor apartó existencia de %s al mismo tiempo, solo quedan %.2f kg disponibles",
+			code, max(available, 0))
+	}
+	log.Printf("%s reserved %.2f kg of %s for %s...\n", identity.User, kg, code, cliente)
+
+	return stockReservation{
+		Reserva:       id,
+		Codigo:        product.Codigo,
+		Descripcion:   product.Descripcion,
+		Kg:            kg,
+		Cliente:       cliente,
+		NumeroCliente: strings.TrimSpace(args.NumeroCliente),
+		Vence:         created.Add(ttl).Format(quoteDateLayout),
+		Estado:        reservationActive,
+		DisponibleKg:  available,
+	}, nil
+}
+
+func extendReservation(ctx context.Context, queries *database.Queries, cfg Config, args extendReservationArgs) (any, error) {
+	ttl, err := reservationTTL(cfg, args.Horas)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := loadOwnReservation(ctx, queries, args.Reserva)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timeNow().Truncate(time.Second)
+	result, err := queries.ExtendStockReservation(ctx, database.ExtendStockReservationParams{
+		Vence: now.Add(ttl),
+		ID:    reservation.ID,
+		Ahora: now,
+	})
+	var extended int64
+	if err == nil {
+		extended, err = result.RowsAffected()
+	}
+	if err != nil {
+		return nil, executionError("ocurrió un error al extender la reserva", err)
+	}
+	if extended == 0 {
+		return nil, invalidArgumentError("la reserva %d ya venció o fue liberada, aparta la existencia de nuevo", reservation.ID)
+	}
+
+	reservation.Vence = now.Add(ttl)
+	described, err := describeReservations(ctx, queries, []database.AiStockReservation{reservation})
+	if err != nil {
+		return nil, err
+	}
+	return described[0], nil
+}
+
+func releaseReservation(ctx context.Context, queries *database.Queries, args reservationArgs) (any, error) {
+	reservation, err := loadOwnReservation(ctx, queries, args.Reserva)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timeNow().Truncate(time.Second)
+	result, err := queries.ReleaseStockReservation(ctx, database.ReleaseStockReservationParams{
+		Liberada: sql.NullTime{Time: now, Valid: true},
+		ID:       reservation.ID,
+	})
+	var released int64
+	if err == nil {
+		released, err = result.RowsAffected()
+	}
+	if err != nil {
+		return nil, executionError("ocurrió un error al liberar la reserva", err)
+	}
+	if released == 0 {
+		return nil, invalidArgumentError("la reserva %d ya fue liberada", reservation.ID)
+	}
+
+	reservation.Liberada = sql.NullTime{Time: now, Valid: true}
+	described, err := describeReservations(ctx, queries, []database.AiStockReservation{reservation})
+	if err != nil {
+		return nil, err
+	}
+	return described[0], nil
+}
+
+// getReservations lists the active reservations of the rep
+func getReservations(ctx context.Context, queries *database.Queries, args noArgs) (any, error) {
+	identity, _ := identityFromContext(ctx)
+	reservations, err := queries.ListActiveStockReservations(ctx, database.ListActiveStockReservationsParams{
+		Usuario: identity.User,
+		Ahora:   timeNow(),
+	})
+	if err != nil {
+		return nil, executionError("ocurrió un error al obtener las reservas", err)
+	}
+	return describeReservations(ctx, queries, reservations)
+}
+
+// loadOwnReservation reads a reservation of the rep. Managers can handle any reservation.
+func loadOwnReservation(ctx context.Context, queries *database.Queries, id int64) (database.AiStockReservation, error) {
+	reservation, err := queries.GetStockReservation(ctx, id)
+	identity, _ := identityFromContext(ctx)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && identity.Role != roleManager && reservation.Usuario != identity.User) {
+		return database.AiStockReservation{}, invalidArgumentError("la reserva %d no existe, consulta tus reservas con obtenerReservas", id)
+	}
+	if err != nil {
+		return database.AiStockReservation{}, executionError("ocurrió un error al obtener la reserva", err)
+	}
+	return reservation, nil
+}
+
+// describeReservations adds the stock still available of every product to the reservations
+func describeReservations(ctx context.Context, queries *database.Queries, reservations []database.AiStockReservation) ([]stockReservation, error) {
+	var codes []string
+	for _, r := range reservations {
+		codes = append(codes, r.Codigo)
+	}
+	available := map[string]float64{}
+	if len(codes) > 0 {
+		levels, err := queries.GetProductStockLevels(ctx, codes)
+		if err != nil {
+			return nil, executionError("ocurrió un error al revisar la existencia", err)
+		}
+		holds, err := activeHolds(ctx, queries, codes)
+		if err != nil {
+			return nil, err
+		}
+		for _, level := range levels {
+			available[level.Codigo] = availableToPromise(level.ExistenciaKg, holds[level.Codigo])
+		}
+	}
+
+	described := []stockReservation{}
+	for _, r := range reservations {
+		status := reservationActive
+		if r.Liberada.Valid {
+			status = reservationReleased
+		}
+		described = append(described, stockReservation{
+			Reserva:       r.ID,
+			Codigo:        r.Codigo,
+			Descripcion:   r.Descripcion,
+			Kg:            r.Kg,
+			Cliente:       r.Cliente,
+			NumeroCliente: r.NumeroCliente,
+			Vence:         r.Vence.Format(quoteDateLayout),
+			Estado:        status,
+			DisponibleKg:  available[r.Codigo],
+		})
+	}
+	return described, nil
+}
+
+// reservationTTL returns how long a reservation lasts for the hours asked by the model
+func reservationTTL(cfg Config, hours float64) (time.Duration, error) {
+	if hours < 0 {
+		return 0, invalidArgumentError("horas debe ser mayor a cero")
+	}
+	if hours == 0 {
+		return cfg.ReservationTTL, nil
+	}
+	ttl := time.Duration(hours * float64(time.Hour))
+	if ttl > cfg.ReservationMaxTTL {
+		return 0, invalidArgumentError("una reserva no puede durar más de %.0f horas", cfg.ReservationMaxTTL.Hours())
+	}
+	return ttl, nil
+}
+
+// activeHolds returns the kg held by the active reservations of every code
+func activeHolds(ctx context.Context, queries *database.Queries, codes []string) (map[string]float64, error) {
+	rows, err := queries.GetActiveStockHolds(ctx, database.GetActiveStockHoldsParams{Ahora: timeNow(), ProductCodes: codes})
+	if err != nil {
+		return nil, executionError("ocurrió un error al obtener las reservas de existencia", err)
+	}
+	holds := map[string]float64{}
+	for _, row := range rows {
+		holds[row.Codigo] = row.ReservadoKg
+	}
+	return holds, nil
+}
+
+// availableStock returns the stock of the product minus its active holds, which is negative
+// when the holds go over the stock
+func availableStock(ctx context.Context, queries *database.Queries, product database.GetProductStockLevelsRow) (float64, error) {
+	holds, err := activeHolds(ctx, queries, []string{product.Codigo})
+	if err != nil {
+		return 0, err
+	}
+	return roundCents(product.ExistenciaKg - holds[product.Codigo]), nil
+}
+
+// availableToPromise is the stock that can still be promised to a customer, never below zero
+func availableToPromise(stock, held float64) float64 {
+	if held <= 0 {
+		return stock
+	}
+	return max(roundCents(stock-held), 0)
+}
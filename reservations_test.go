@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A reservation lowers the stock every rep can promise until it is released
+func TestStockReservations(t *testing.T) {
+	fixClock(t, testNow)
+
+	call := func(name string, args map[string]any) scriptedTurn {
+		return scriptedTurn{ToolCalls: []ToolCall{{ID: "call-" + name, Name: name, Args: args}}}
+	}
+	info := map[string]any{"productCodes": []any{"101"}}
+	provider := newFakeProvider(
+		call("apartarExistencia", map[string]any{"codigo": "101", "kg": 100.0, "cliente": "Carnicería Don Pepe"}),
+		scriptedTurn{Text: "Listo, aparté 100 kg."},
+
+		scriptedTurn{ToolCalls: []ToolCall{
+			{ID: "call-1", Name: "apartarExistencia", Args: map[string]any{"codigo": "101", "kg": 30.0, "cliente": "Pollería Ruiz"}},
+			{ID: "call-2", Name: "obtenerInformacionPorCodigo", Args: info},
+			{ID: "call-3", Name: "liberarReserva", Args: map[string]any{"reserva": 1.0}},
+		}},
+		scriptedTurn{Text: "Solo quedan 20.50 kg."},
+
+		call("extenderReserva", map[string]any{"reserva": 1.0, "horas": 48.0}),
+		call("liberarReserva", map[string]any{"reserva": 1.0}),
+		call("obtenerInformacionPorCodigo", info),
+		scriptedTurn{Text: "La reserva quedó liberada."},
+	)
+	srv := newTestServer(t, provider)
+
+	chat := func(apiKey string) []string {
+		t.Helper()
+		resp, body := postChat(t, srv, apiKey, userRequest("aparta pechuga"))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+		}
+		var responses []string
+		for _, result := range provider.toolResults() {
+			response, _ := json.Marshal(result.Response)
+			responses = append(responses, string(response))
+		}
+		return responses
+	}
+	expect := func(response string, want ...string) {
+		t.Helper()
+		for _, w := range want {
+			if !strings.Contains(response, w) {
+				t.Errorf("response %s does not contain %s", response, w)
+			}
+		}
+	}
+
+	got := chat(testAPIKey)
+	expect(got[0], `"Reserva":1`, `"DisponibleKg":20.5`, `"Vence":"11/03/2025 09:30"`)
+
+	got = chat(testOtherAPIKey)
+	expect(got[0], "solo hay 20.50 kg disponibles de 101")
+	expect(got[1], `"ExistenciaKg":20.5`)
+	expect(got[2], "la reserva 1 no existe")
+
+	got = chat(testAPIKey)
+	expect(got[0], `"Vence":"12/03/2025 09:30"`, `"Estado":"activa"`)
+	expect(got[1], `"Estado":"liberada"`, `"DisponibleKg":120.5`)
+	expect(got[2], `"ExistenciaKg":120.5`)
+}
+
+// Reps reserving the same product at the same time never hold more than its stock, and every
+// reservation that fits is made
+func TestReserveStockConcurrently(t *testing.T) {
+	fixClock(t, testNow)
+	db := newFakeDB(testCatalog)
+	t.Cleanup(func() { db.Close() })
+	queries := database.New(db)
+	cfg := Config{ReservationTTL: 24 * time.Hour, ReservationMaxTTL: 72 * time.Hour}
+
+	// 120.5 kg of 101 fit four reservations of 30 kg
+	var wg sync.WaitGroup
+	errs := make([]error, 6)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = reserveStock(context.Background(), queries, cfg, reserveStockArgs{Codigo: "101", Kg: 30, Cliente: "Carnicería Don Pepe"})
+		}()
+	}
+	wg.Wait()
+
+	var reserved int
+	for _, err := range errs {
+		var toolErr *ToolError
+		switch {
+		case err == nil:
+			reserved++
+		case !errors.As(err, &toolErr) || toolErr.Code != toolErrInvalidArgument:
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if reserved != 4 {
+		t.Errorf("made %d reservations, want 4", reserved)
+	}
+	holds, err := activeHolds(context.Background(), queries, []string{"101"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holds["101"] != 120 {
+		t.Errorf("held %v kg of 101, want 120", holds["101"])
+	}
+}
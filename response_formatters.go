@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const responseHeader = `*¡Hola! 😊 Gracias por tu interés en nuestros productos!*
+
+🚚 Hacemos entregas en Tula, Tepeji, Chapantongo, Jilotepec, Huehuetoca, Ixmiquilpan, Mixquiahuala y alrededores.`
+
+const responseFooter = `📍 También puedes visitarnos aquí: https://maps.app.goo.gl/QDv4HnqqJhqQ24BP8?g_st=ac
+📲 Mándanos mensaje por WhatsApp: https://wa.me/527731819900
+🐔 *COPOCAR* agradece tu preferencia!🙏`
+
+// responseFormatter is the output post-processor of a profile. It wraps the model
+// text with a header and footer, which lets streaming send the header right away.
+type responseFormatter struct {
+	Header string
+	Footer string
+}
+
+// Formatters that profiles can select by name
+var responseFormatters = map[string]responseFormatter{
+	// Ready to paste in WhatsApp for customers
+	"whatsapp": {Header: responseHeader, Footer: responseFooter},
+	// Model text as is, for internal users
+	"plain": {},
+}
+
+func getResponseFormatter(name string) (responseFormatter, error) {
+	formatter, ok := responseFormatters[name]
+	if !ok {
+		return responseFormatter{}, fmt.Errorf("unknown formatter %q", name)
+	}
+	return formatter, nil
+}
+
+// prefix is the text sent before the model text
+func (f responseFormatter) prefix() string {
+	if f.Header == "" {
+		return ""
+	}
+	return f.Header + "\n\n\n"
+}
+
+// suffix is the text sent after the model text
+func (f responseFormatter) suffix() string {
+	if f.Footer == "" {
+		return ""
+	}
+	return "\n\n\n" + f.Footer
+}
+
+func (f responseFormatter) format(response string) string {
+	return f.prefix() + strings.TrimSpace(response) + f.suffix()
+}
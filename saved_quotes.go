@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Date format of the quotes shown to customers
+const quoteDateLayout = "02/01/2006 15:04"
+
+type saveQuoteArgs struct {
+	Cliente   string          `json:"cliente" desc:"Nombre del cliente al que se entrega la cotización."`
+	Productos []quoteItemArgs `json:"productos" desc:"Productos de la cotización con su cantidad."`
+	Notas     string          `json:"notas,omitempty" desc:"Notas opcionales para el cliente, por ejemplo condiciones de entrega."`
+}
+
+// savedQuote is the answer of guardarCotizacion, with the links to export the quote
+type savedQuote struct {
+	Folio    int64
+	Cliente  string
+	Vence    string
+	Subtotal float64
+	Iva      float64
+	Total    float64
+	Lineas   []quoteLine
+	// vigente, or pendiente de aprobación when a price is out of the pricing policy
+	Estado           string
+	FueraDePolitica  []policyViolation
+	AvisosExistencia []stockWarning
+	Pdf              string
+	WhatsApp         string
+}
+
+// Status of a saved quote as told to the model
+const (
+	quoteStatusValid   = "vigente"
+	quoteStatusPending = "pendiente de aprobación"
+)
+
+// saveQuoteTool returns the guardarCotizacion tool. The quote is priced again by the server
+// and stored for the customer with an expiry date, since the grupos prices change.
+func saveQuoteTool(cfg Config, policy pricingPolicy) FunctionTool {
+	tool := newFunctionTool("guardarCotizacion",
+		"Guarda una cotización para un cliente con su fecha de vencimiento y devuelve el folio y "+
+			"los enlaces para descargarla en PDF o como texto para WhatsApp. Los importes se calculan igual que en cotizar.",
+		func(ctx context.Context, queries *database.Queries, args saveQuoteArgs) (any, error) {
+			return saveQuote(ctx, queries, cfg, policy, args)
+		})
+	tool.Writes = true
+	return tool
+}
+
+func saveQuote(ctx context.Context, queries *database.Queries, cfg Config, policy pricingPolicy, args saveQuoteArgs) (any, error) {
+	cliente := strings.TrimSpace(args.Cliente)
+	if cliente == "" {
+		return nil, invalidArgumentError("falta el nombre del cliente")
+	}
+
+	q, err := buildQuote(ctx, queries, policy, args.Productos)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, _ := identityFromContext(ctx)
+	created := timeNow().Truncate(time.Second)
+	expires := created.Add(cfg.QuoteValidity)
+
+	result, err := queries.CreateQuote(ctx, database.CreateQuoteParams{
+		Cliente:  cliente,
+		Notas:    strings.TrimSpace(args.Notas),
+		Usuario:  identity.User,
+		Sucursal: identity.Branch,
+		Subtotal: q.Subtotal,
+		Iva:      q.Iva,
+		Total:    q.Total,
+		Creada:   created,
+		Vence:    expires,
+	})
+	if err != nil {
+		return nil, executionError("ocurrió un error al guardar la cotización", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, executionError("ocurrió un error al guardar la cotización", err)
+	}
+
+	// Do not leave a quote without all of its lines or without its approval request. The request
+	// context may be the cause of the failure, so the cleanup gets its own deadline.
+	abort := func(err error) (any, error) {
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		if err := queries.DeleteQuote(cleanupCtx, id); err != nil {
+			log.Printf("failed to delete incomplete quote %d: %v\n", id, err)
+		}
+		return nil, executionError("ocurrió un error al guardar la cotización", err)
+	}
+
+	for _, line := range q.Lineas {
+		err := queries.CreateQuoteLine(ctx, database.CreateQuoteLineParams{
+			QuoteID:     id,
+			Codigo:      line.Codigo,
+			Descripcion: line.Descripcion,
+			Cantidad:    line.Cantidad,
+			Unidad:      line.Unidad,
+			Kg:          line.Kg,
+			Lista:       line.Lista,
+			PrecioKg:    line.PrecioKg,
+			Importe:     line.Importe,
+			TasaIva:     line.TasaIva,
+			Iva:         line.Iva,
+		})
+		if err != nil {
+			return abort(err)
+		}
+	}
+
+	status := quoteStatusValid
+	if q.RequiereAprobacion {
+		_, err := queries.CreateQuoteApproval(ctx, database.CreateQuoteApprovalParams{
+			QuoteID: id,
+			Usuario: identity.User,
+			Motivos: approvalReasons(q.FueraDePolitica),
+			Creada:  created,
+		})
+		if err != nil {
+			return abort(err)
+		}
+		status = quoteStatusPending
+		log.Printf("quote %d of %s is out of the pricing policy, waiting for approval...\n", id, identity.User)
+	}
+
+	return savedQuote{
+		Folio:            id,
+		Cliente:          cliente,
+		Vence:            expires.Format(quoteDateLayout),
+		Subtotal:         q.Subtotal,
+		Iva:              q.Iva,
+		Total:            q.Total,
+		Lineas:           q.Lineas,
+		Estado:           status,
+		FueraDePolitica:  q.FueraDePolitica,
+		AvisosExistencia: q.AvisosExistencia,
+		Pdf:              quoteExportURL(cfg, id, "pdf"),
+		WhatsApp:         quoteExportURL(cfg, id, "whatsapp"),
+	}, nil
+}
+
+// quoteExportURL returns the link to export a quote, signed so it opens without an API key
+// until the quote would expire if it were saved now
+func quoteExportURL(cfg Config, id int64, format string) string {
+	expires := timeNow().Add(cfg.QuoteValidity).Unix()
+	return fmt.Sprintf("%s/v1/quotes/%d/%s?expires=%d&signature=%s", strings.TrimSuffix(cfg.PublicBaseURL, "/"),
+		id, format, expires, quoteLinkSignature(cfg.QuoteLinkKey, id, expires))
+}
+
+// quoteLinkSignature is the hex HMAC-SHA256 of the quote id and the expiry of its link
+func quoteLinkSignature(key []byte, id, expires int64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%d:%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requireQuoteLink serves the quote exports for the signed links given by the agent, which the
+// rep opens in a browser or forwards, and asks for the API key otherwise
+func (app *App) requireQuoteLink(next http.HandlerFunc) http.HandlerFunc {
+	withAPIKey := app.requireAPIKey(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if !query.Has("signature") {
+			withAPIKey(w, r)
+			return
+		}
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		expires, expiresErr := strconv.ParseInt(query.Get("expires"), 10, 64)
+		if err != nil || expiresErr != nil ||
+			!hmac.Equal([]byte(query.Get("signature")), []byte(quoteLinkSignature(app.config.QuoteLinkKey, id, expires))) {
+			writeOpenAIError(w, http.StatusForbidden, "invalid_request_error", "invalid_signature", "The quote link is not valid")
+			return
+		}
+		if timeNow().Unix() > expires {
+			writeOpenAIError(w, http.StatusGone, "invalid_request_error", "link_expired", "The quote link expired, ask the agent for a new one")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// quoteExportHandler serves GET /v1/quotes/{id}/{format}, with format pdf or whatsapp.
+// Sales reps can only export their own quotes with their API key, a signed link opens the quote
+// it was made for. Expired or unapproved quotes are not served.
+func (app *App) quoteExportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.PathValue("format")
+	if format != "pdf" && format != "whatsapp" {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "invalid_format", "format must be pdf or whatsapp")
+		return
+	}
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "quote_not_found", "Quote not found")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), app.config.DBQueryTimeout)
+	defer cancel()
+
+	stored, err := app.queries.GetQuote(ctx, id)
+	identity, byAPIKey := identityFromContext(r.Context())
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && byAPIKey && identity.Role != roleManager && stored.Usuario != identity.User) {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "quote_not_found", "Quote not found")
+		return
+	}
+	if err != nil {
+		log.Printf("failed to get quote %d: %v\n", id, err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "database_error", "Failed to get the quote")
+		return
+	}
+	if timeNow().After(stored.Vence) {
+		writeOpenAIError(w, http.StatusGone, "invalid_request_error", "quote_expired",
+			fmt.Sprintf("The quote expired on %s, prices must be quoted again", stored.Vence.Format(quoteDateLayout)))
+		return
+	}
+
+	// Quotes out of the pricing policy only reach the customer once a manager approves them
+	approval, err := app.queries.GetQuoteApprovalByQuote(ctx, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		log.Printf("failed to get approval of quote %d: %v\n", id, err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "database_error", "Failed to get the quote")
+		return
+	case approval.Estado == approvalPending:
+		writeOpenAIError(w, http.StatusConflict, "invalid_request_error", "quote_pending_approval",
+			"The quote has prices out of the pricing policy and is waiting for a manager approval")
+		return
+	case approval.Estado == approvalRejected:
+		writeOpenAIError(w, http.StatusConflict, "invalid_request_error", "quote_rejected",
+			"The quote was rejected by a manager, prices must be quoted again")
+		return
+	}
+
+	lines, err := app.queries.GetQuoteLines(ctx, id)
+	if err != nil {
+		log.Printf("failed to get lines of quote %d: %v\n", id, err)
+		writeOpenAIError(w, http.StatusInternalServerError, "server_error", "database_error", "Failed to get the quote")
+		return
+	}
+
+	switch format {
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="cotizacion-%d.pdf"`, id))
+		w.Write(quotePDF(stored, lines))
+	case "whatsapp":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(quoteWhatsAppText(stored, lines)))
+	}
+}
+
+// quoteWhatsAppText renders a stored quote ready to paste in WhatsApp, with the store footer
+func quoteWhatsAppText(q database.AiQuote, lines []database.AiQuoteLine) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*COTIZACIÓN #%d* 🧾\n", q.ID)
+	fmt.Fprintf(&text, "👤 *Cliente:* %s\n", q.Cliente)
+	fmt.Fprintf(&text, "📅 *Fecha:* %s\n", q.Creada.Format(quoteDateLayout))
+	fmt.Fprintf(&text, "⏳ *Válida hasta:* %s\n", q.Vence.Format(quoteDateLayout))
+
+	for _, line := range lines {
+		fmt.Fprintf(&text, "\n*%s* (%s)\n", line.Descripcion, line.Codigo)
+		fmt.Fprintf(&text, "* %s = %.2f Kg x %s/Kg (%s)\n", quoteQuantity(line), line.Kg, formatMoney(line.PrecioKg), line.Lista)
+		fmt.Fprintf(&text, "* Importe: %s", formatMoney(line.Importe))
+		if line.Iva > 0 {
+			fmt.Fprintf(&text, " + IVA %s", formatMoney(line.Iva))
+		}
+		text.WriteString("\n")
+	}
+
+	fmt.Fprintf(&text, "\n💲 *Subtotal:* %s\n", formatMoney(q.Subtotal))
+	fmt.Fprintf(&text, "🧾 *IVA:* %s\n", formatMoney(q.Iva))
+	fmt.Fprintf(&text, "💰 *Total:* %s\n", formatMoney(q.Total))
+	if q.Notas != "" {
+		fmt.Fprintf(&text, "\n📝 %s\n", q.Notas)
+	}
+
+	text.WriteString("\n" + responseFooter)
+	return text.String()
+}
+
+// quotePDF renders a stored quote as a one column PDF with a fixed width table of lines
+func quotePDF(q database.AiQuote, lines []database.AiQuoteLine) []byte {
+	mono := func(format string, args ...any) pdfLine {
+		return pdfLine{Font: pdfFontMono, Size: 9, Text: fmt.Sprintf(format, args...)}
+	}
+	row := "%-8s %-28s %10s %9s %-13s %10s %12s"
+	rule := strings.Repeat("-", 96)
+
+	doc := []pdfLine{
+		{Font: pdfFontBold, Size: 16, Text: "COPOCAR"},
+		{Font: pdfFontBold, Size: 13, Text: fmt.Sprintf("Cotización #%d", q.ID)},
+		{Font: pdfFontMono, Size: 9},
+		mono("Cliente:      %s", q.Cliente),
+		mono("Fecha:        %s", q.Creada.Format(quoteDateLayout)),
+		mono("Válida hasta: %s", q.Vence.Format(quoteDateLayout)),
+		mono("Atendió:      %s %s", q.Usuario, q.Sucursal),
+		{Font: pdfFontMono, Size: 9},
+		mono(row, "Código", "Descripción", "Cantidad", "Kg", "Lista", "Precio/Kg", "Importe"),
+		mono("%s", rule),
+	}
+	for _, line := range lines {
+		doc = append(doc, mono(row, line.Codigo, truncateRunes(line.Descripcion, 28), quoteQuantity(line),
+			fmt.Sprintf("%.2f", line.Kg), line.Lista, formatMoney(line.PrecioKg), formatMoney(line.Importe)))
+	}
+	doc = append(doc,
+		mono("%s", rule),
+		mono("%83s %12s", "Subtotal", formatMoney(q.Subtotal)),
+		mono("%83s %12s", "IVA", formatMoney(q.Iva)),
+		mono("%83s %12s", "Total", formatMoney(q.Total)),
+	)
+	if q.Notas != "" {
+		doc = append(doc, pdfLine{Font: pdfFontMono, Size: 9}, mono("Notas: %s", q.Notas))
+	}
+
+	doc = append(doc, pdfLine{Font: pdfFontMono, Size: 9})
+	// The emojis leading the footer lines have no glyph in the PDF fonts, writeTextPDF encodes the rest
+	for _, footer := range strings.Split(responseFooter, "\n") {
+		footer = strings.TrimLeftFunc(strings.ReplaceAll(footer, "*", ""), func(r rune) bool { return r > 0xff || unicode.IsSpace(r) })
+		doc = append(doc, mono("%s", footer))
+	}
+	return writeTextPDF(doc)
+}
+
+// quoteQuantity writes the quantity as ordered, e.g. "3 cajas" or "12.50 kg"
+func quoteQuantity(line database.AiQuoteLine) string {
+	return formatFigure(line.Cantidad, "") + " " + line.Unidad
+}
+
+// formatMoney writes an amount with thousands separators, e.g. $13,491.00
+func formatMoney(value float64) string {
+	cents := fmt.Sprintf("%.2f", value)
+	whole, decimals, _ := strings.Cut(strings.TrimPrefix(cents, "-"), ".")
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	if strings.HasPrefix(cents, "-") {
+		return "-$" + whole + "." + decimals
+	}
+	return "$" + whole + "." + decimals
+}
+
+func truncateRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveTestQuote runs the chat so the scripted model saves a quote
+func saveTestQuote(t *testing.T, srv *httptest.Server) {
+	t.Helper()
+
+	resp, body := postChat(t, srv, testAPIKey, userRequest("guarda la cotización"))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+}
+
+func getQuote(t *testing.T, srv *httptest.Server, apiKey, path string) (*http.Response, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	return resp, body.Bytes()
+}
+
+func TestQuoteExport(t *testing.T) {
+	fixClock(t, testNow)
+
+	provider := newFakeProvider(
+		scriptedTurn{ToolCalls: []ToolCall{{ID: "call-1", Name: "guardarCotizacion", Args: map[string]any{
+			"cliente": "Carnicería Don Pepe",
+			"notas":   "Entrega el martes",
+			"productos": []any{
+				map[string]any{"codigo": "101", "cantidad": 3.0, "unidad": "cajas"},
+				map[string]any{"codigo": "205", "cantidad": 50.0, "unidad": "piezas"},
+			},
+		}}}},
+		scriptedTurn{Text: "Listo, la cotización quedó guardada con el folio 1."},
+	)
+	srv := newTestServer(t, provider)
+	saveTestQuote(t, srv)
+
+	resp, body := getQuote(t, srv, testAPIKey, "/v1/quotes/1/whatsapp")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	assertGolden(t, "quote_whatsapp", body)
+
+	resp, body = getQuote(t, srv, testAPIKey, "/v1/quotes/1/pdf")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/pdf" {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !bytes.HasPrefix(body, []byte("%PDF-1.4")) || !bytes.HasSuffix(body, []byte("%%EOF\n")) {
+		t.Errorf("the response is not a PDF document")
+	}
+	if !bytes.Contains(body, []byte(`(Cliente:      Carnicer\355a Don Pepe)`)) {
+		t.Errorf("the PDF does not show the customer in WinAnsiEncoding")
+	}
+	if !bytes.Contains(body, []byte(`(Tambi\351n puedes visitarnos`)) {
+		t.Errorf("the PDF does not show the footer in WinAnsiEncoding")
+	}
+
+	// The links given by the tool open without an API key, so the rep can forward them
+	var saved struct{ Result savedQuote }
+	response, _ := json.Marshal(provider.toolResults()[0].Response)
+	if err := json.Unmarshal(response, &saved); err != nil {
+		t.Fatal(err)
+	}
+	signed := strings.TrimPrefix(saved.Result.WhatsApp, "https://agente.example.com")
+
+	tests := []struct {
+		name   string
+		apiKey string
+		path   string
+		now    time.Time
+		status int
+		code   string
+	}{
+		{"other user", testOtherAPIKey, "/v1/quotes/1/pdf", testNow, http.StatusNotFound, "quote_not_found"},
+		{"missing quote", testAPIKey, "/v1/quotes/2/pdf", testNow, http.StatusNotFound, "quote_not_found"},
+		{"unknown format", testAPIKey, "/v1/quotes/1/xlsx", testNow, http.StatusNotFound, "invalid_format"},
+		{"expired", testAPIKey, "/v1/quotes/1/whatsapp", testNow.Add(49 * time.Hour), http.StatusGone, "quote_expired"},
+		{"no api key", "", "/v1/quotes/1/pdf", testNow, http.StatusUnauthorized, "missing_api_key"},
+		{"signed link", "", signed, testNow, http.StatusOK, "COTIZACIÓN"},
+		{"signed link of other quote", "", strings.Replace(signed, "/1/", "/2/", 1), testNow, http.StatusForbidden, "invalid_signature"},
+		{"signed link with other expiry", "", strings.Replace(signed, "expires=", "expires=9", 1), testNow, http.StatusForbidden, "invalid_signature"},
+		{"expired signed link", "", signed, testNow.Add(49 * time.Hour), http.StatusGone, "link_expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixClock(t, tt.now)
+			resp, body := getQuote(t, srv, tt.apiKey, tt.path)
+			if resp.StatusCode != tt.status || !strings.Contains(string(body), tt.code) {
+				t.Errorf("got %d %s, want %d %s", resp.StatusCode, body, tt.status, tt.code)
+			}
+		})
+	}
+}
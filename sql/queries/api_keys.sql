@@ -0,0 +1,10 @@
+-- name: GetActiveAPIKey :one
+SELECT
+  usuario,
+  nombre,
+  sucursal,
+  rol
+FROM ai_api_keys
+WHERE
+  key_hash = ?
+  AND activo = TRUE;
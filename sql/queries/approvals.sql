@@ -0,0 +1,59 @@
+-- name: CreateQuoteApproval :execresult
+INSERT INTO ai_quote_approvals (quote_id, usuario, motivos, creada)
+VALUES (?, ?, ?, ?);
+
+-- name: GetQuoteApproval :one
+SELECT id, quote_id, usuario, motivos, estado, revisor, comentario, creada, resuelta, notificada
+FROM ai_quote_approvals
+WHERE id = ?;
+
+-- name: GetQuoteApprovalByQuote :one
+SELECT id, quote_id, usuario, motivos, estado, revisor, comentario, creada, resuelta, notificada
+FROM ai_quote_approvals
+WHERE quote_id = ?
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListQuoteApprovals :many
+SELECT
+  ap.id,
+  ap.quote_id,
+  ap.usuario,
+  ap.motivos,
+  ap.estado,
+  ap.revisor,
+  ap.comentario,
+  ap.creada,
+  ap.resuelta,
+  q.cliente,
+  q.total
+FROM ai_quote_approvals ap
+JOIN ai_quotes q ON ap.quote_id = q.id
+WHERE ap.estado = ?
+ORDER BY ap.creada;
+
+-- name: ListUnnotifiedApprovals :many
+SELECT
+  ap.id,
+  ap.quote_id,
+  ap.estado,
+  ap.revisor,
+  ap.comentario,
+  q.cliente
+FROM ai_quote_approvals ap
+JOIN ai_quotes q ON ap.quote_id = q.id
+WHERE
+  ap.usuario = ?
+  AND ap.estado != 'pendiente'
+  AND ap.notificada = FALSE
+ORDER BY ap.resuelta;
+
+-- name: MarkApprovalNotified :exec
+UPDATE ai_quote_approvals
+SET notificada = TRUE
+WHERE id = ?;
+
+-- name: ResolveQuoteApproval :execresult
+UPDATE ai_quote_approvals
+SET estado = ?, revisor = ?, comentario = ?, resuelta = ?
+WHERE id = ? AND estado = 'pendiente';
@@ -0,0 +1,31 @@
+-- name: GetProductCosts :many
+SELECT
+  a.vcodpro AS codigo,
+  a.vdescri AS descripcion,
+  a.vcospr1 AS costo_ultimo,
+  a.vcospr2 AS costo_anterior,
+  a.vpreact AS precio_actual,
+  a.vfacref AS factor_referencia,
+  g.fac1 AS precio_detalle,
+  g.fac2 AS precio_medio_mayoreo,
+  g.fac3 AS precio_mayoreo
+FROM articulos a
+JOIN grupos g ON a.vcodpro = g.grupo
+WHERE
+  a.vcodpro IN (sqlc.slice('product_codes'))
+  AND a.vtippro = 1;
+
+-- name: GetProductPolicyData :many
+SELECT
+  a.vcodpro AS codigo,
+  a.vdescri AS descripcion,
+  l.vdescri AS linea,
+  a.vmarart AS marca,
+  a.vcospr1 AS costo_ultimo,
+  g.fac3 AS precio_mayoreo
+FROM articulos a
+JOIN lineas l ON a.vlinart = l.vlindep
+JOIN grupos g ON a.vcodpro = g.grupo
+WHERE
+  a.vcodpro IN (sqlc.slice('product_codes'))
+  AND a.vtippro = 1;
@@ -0,0 +1,100 @@
+-- name: SearchCustomers :many
+SELECT
+  m.vnumcte AS numero,
+  m.vrazons AS razon_social,
+  MAX(m.vfecham) AS ultima_compra,
+  COUNT(DISTINCT m.vfoliog) AS compras
+FROM movimientosd m
+WHERE
+  m.vtipmov = 'caj01'
+  AND m.vnumcte != ''
+  AND (
+    m.vnumcte = sqlc.arg(busqueda) OR
+    m.vrazons LIKE CONCAT('%', sqlc.arg(busqueda), '%')
+  )
+GROUP BY
+  m.vnumcte, m.vrazons
+ORDER BY ultima_compra DESC
+LIMIT 10;
+
+-- name: GetCustomerSales :many
+SELECT
+  m.vfoliog AS folio,
+  m.vfecham AS fecha,
+  m.vrazons AS razon_social,
+  m.vcodpro AS codigo,
+  m.vdescri AS descripcion,
+  m.vcantid AS cantidad,
+  m.vimport AS importe,
+  m.vnivpre AS nivel_precio,
+  m.vprelis AS precio_lista,
+  m.vdto001,
+  m.vdto002,
+  m.vdto003,
+  m.vdto004,
+  m.vdto005,
+  m.vdto006,
+  m.vdto007,
+  m.vdto008,
+  m.vdto009,
+  m.vdto010
+FROM movimientosd m
+WHERE
+  m.vtipmov = 'caj01'
+  AND m.vnumcte = sqlc.arg(numero_cliente)
+  AND m.vfecham >= sqlc.arg(desde)
+  AND m.vcantid > 0
+ORDER BY m.vfecham DESC, m.vfoliog DESC, m.vrenglo;
+
+-- name: GetProductPriceLevels :many
+SELECT
+  a.vcodpro AS codigo,
+  a.vdescri AS descripcion,
+  g.fac1,
+  g.fac2,
+  g.fac3,
+  g.fac4,
+  g.fac5,
+  g.fac6
+FROM articulos a
+JOIN grupos g ON a.vcodpro = g.grupo
+WHERE
+  a.vcodpro IN (sqlc.slice('product_codes'))
+  AND a.vtippro = 1;
+
+-- name: GetCustomerOrders :many
+SELECT
+  m.vfoliog AS folio,
+  MAX(m.vrazons) AS razon_social,
+  MIN(m.vfecham) AS fecha,
+  COUNT(*) AS renglones,
+  SUM(m.vcantid) AS kg,
+  SUM(m.vimport) AS importe
+FROM movimientosd m
+WHERE
+  m.vtipmov = 'caj01'
+  AND m.vnumcte = sqlc.arg(numero_cliente)
+  AND m.vfecham >= sqlc.arg(desde)
+  AND m.vcantid > 0
+GROUP BY
+  m.vfoliog
+ORDER BY fecha DESC, folio DESC;
+
+-- name: GetCustomerProductHistory :many
+SELECT
+  m.vcodpro AS codigo,
+  MAX(m.vdescri) AS descripcion,
+  COUNT(DISTINCT m.vfoliog) AS pedidos,
+  SUM(m.vcantid) AS kg,
+  SUM(m.vimport) AS importe,
+  MIN(m.vfecham) AS primera_compra,
+  MAX(m.vfecham) AS ultima_compra
+FROM movimientosd m
+WHERE
+  m.vtipmov = 'caj01'
+  AND m.vnumcte = sqlc.arg(numero_cliente)
+  AND m.vfecham >= sqlc.arg(desde)
+  AND m.vcantid > 0
+GROUP BY
+  m.vcodpro
+ORDER BY pedidos DESC, kg DESC;
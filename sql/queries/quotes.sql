@@ -0,0 +1,40 @@
+-- name: GetQuoteProducts :many
+SELECT
+  a.vcodpro AS codigo,
+  a.vdescri AS descripcion,
+  a.vmedpes AS peso_promedio_caja_kg,
+  a.vpresen AS piezas_por_caja,
+  a.vivaart AS tasa_iva,
+  g.fac1 AS precio_detalle,
+  g.facd1 AS escala_detalle,
+  g.fac2 AS precio_medio_mayoreo,
+  g.facd2 AS escala_medio_mayoreo,
+  g.fac3 AS precio_mayoreo
+FROM articulos a
+JOIN grupos g ON a.vcodpro = g.grupo
+WHERE
+  a.vcodpro IN (sqlc.slice('product_codes'))
+  AND a.vtippro = 1;
+
+-- name: CreateQuote :execresult
+INSERT INTO ai_quotes (cliente, notas, usuario, sucursal, subtotal, iva, total, creada, vence)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: CreateQuoteLine :exec
+INSERT INTO ai_quote_lines (quote_id, codigo, descripcion, cantidad, unidad, kg, lista, precio_kg, importe, tasa_iva, iva)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteQuote :exec
+DELETE FROM ai_quotes
+WHERE id = ?;
+
+-- name: GetQuote :one
+SELECT id, cliente, notas, usuario, sucursal, subtotal, iva, total, creada, vence
+FROM ai_quotes
+WHERE id = ?;
+
+-- name: GetQuoteLines :many
+SELECT id, quote_id, codigo, descripcion, cantidad, unidad, kg, lista, precio_kg, importe, tasa_iva, iva
+FROM ai_quote_lines
+WHERE quote_id = ?
+ORDER BY id;
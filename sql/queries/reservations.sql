@@ -0,0 +1,63 @@
+-- name: CreateStockReservation :execresult
+INSERT INTO ai_stock_reservations (codigo, descripcion, kg, cliente, numero_cliente, usuario, sucursal, creada, vence)
+SELECT
+  a.vcodpro,
+  a.vdescri,
+  sqlc.arg('kg'),
+  sqlc.arg('cliente'),
+  sqlc.arg('numero_cliente'),
+  sqlc.arg('usuario'),
+  sqlc.arg('sucursal'),
+  sqlc.arg('creada'),
+  sqlc.arg('vence')
+FROM articulos a
+WHERE
+  a.vcodpro = sqlc.arg('codigo')
+  AND a.vtippro = 1
+  AND ROUND(a.vexiact - (
+    SELECT COALESCE(SUM(r.kg), 0)
+    FROM ai_stock_reservations r
+    WHERE
+      r.codigo = a.vcodpro
+      AND r.liberada IS NULL
+      AND r.vence > sqlc.arg('creada')
+  ), 2) >= sqlc.arg('kg')
+FOR UPDATE;
+
+-- name: GetStockReservation :one
+SELECT id, codigo, descripcion, kg, cliente, numero_cliente, usuario, sucursal, creada, vence, liberada
+FROM ai_stock_reservations
+WHERE id = ?;
+
+-- name: ListActiveStockReservations :many
+SELECT id, codigo, descripcion, kg, cliente, numero_cliente, usuario, sucursal, creada, vence, liberada
+FROM ai_stock_reservations
+WHERE
+  usuario = sqlc.arg('usuario')
+  AND liberada IS NULL
+  AND vence > sqlc.arg('ahora')
+ORDER BY vence;
+
+-- name: ExtendStockReservation :execresult
+UPDATE ai_stock_reservations
+SET vence = sqlc.arg('vence')
+WHERE
+  id = sqlc.arg('id')
+  AND liberada IS NULL
+  AND vence > sqlc.arg('ahora');
+
+-- name: ReleaseStockReservation :execresult
+UPDATE ai_stock_reservations
+SET liberada = ?
+WHERE id = ? AND liberada IS NULL;
+
+-- name: GetActiveStockHolds :many
+SELECT
+  codigo,
+  CAST(SUM(kg) AS DOUBLE) AS reservado_kg
+FROM ai_stock_reservations
+WHERE
+  liberada IS NULL
+  AND vence > sqlc.arg('ahora')
+  AND codigo IN (sqlc.slice('product_codes'))
+GROUP BY codigo;
@@ -0,0 +1,77 @@
+-- name: GetStockAlerts :many
+SELECT
+  a.vcodpro AS codigo,
+  a.vdescri AS descripcion,
+  l.vdescri AS linea,
+  a.vmarart AS marca,
+  a.vexiact AS existencia_kg,
+  a.veximin AS minimo_kg,
+  a.veximax AS maximo_kg,
+  a.vexidis AS disponible_kg
+FROM articulos a
+JOIN lineas l ON a.vlinart = l.vlindep
+WHERE
+  a.vtippro = 1
+  AND a.vdescri != ''
+  AND a.vlinart NOT IN ('9', '13')
+  AND (
+    (a.veximin > 0 AND a.vexiact < a.veximin)
+    OR (a.veximax > 0 AND a.vexiact > a.veximax)
+  )
+ORDER BY l.vdescri, a.vdescri;
+
+-- name: GetProductStockLevels :many
+SELECT
+  a.vcodpro AS codigo,
+  a.vdescri AS descripcion,
+  a.vexiact AS existencia_kg,
+  a.veximin AS minimo_kg,
+  a.veximax AS maximo_kg,
+  a.vexidis AS disponible_kg
+FROM articulos a
+WHERE
+  a.vcodpro IN (sqlc.slice('product_codes'))
+  AND a.vtippro = 1;
+
+-- name: GetProductInboundLots :many
+SELECT
+  m.vcodpro AS codigo,
+  m.vfoliog AS folio,
+  m.vfecham AS fecha,
+  m.vcantid AS kg,
+  m.vcaduci AS caducidad
+FROM movimientosd m
+WHERE
+  m.vtipmov = 'ent01'
+  AND m.vcantid > 0
+  AND m.vfecham >= sqlc.arg('desde')
+  AND m.vcodpro IN (sqlc.slice('product_codes'))
+ORDER BY m.vcodpro, m.vfecham DESC, m.vfoliog DESC;
+
+-- name: GetProductsWithExpiringLots :many
+SELECT
+  a.vcodpro AS codigo,
+  a.vdescri AS descripcion,
+  l.vdescri AS linea,
+  a.vexiact AS existencia_kg,
+  g.fac1 AS precio_detalle,
+  g.fac2 AS precio_medio_mayoreo,
+  g.fac3 AS precio_mayoreo
+FROM articulos a
+JOIN lineas l ON a.vlinart = l.vlindep
+JOIN grupos g ON a.vcodpro = g.grupo
+WHERE
+  a.vtippro = 1
+  AND a.vexiact > 0
+  AND EXISTS (
+    SELECT 1
+    FROM movimientosd m
+    WHERE
+      m.vcodpro = a.vcodpro
+      AND m.vtipmov = 'ent01'
+      AND m.vcantid > 0
+      AND m.vfecham >= sqlc.arg('desde')
+      AND m.vcaduci > '2000-01-01'
+      AND m.vcaduci <= sqlc.arg('hasta')
+  )
+ORDER BY l.vdescri, a.vdescri;
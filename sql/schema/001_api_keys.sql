@@ -0,0 +1,11 @@
+-- API keys used by Open WebUI and other clients of the agent.
+-- Only the SHA-256 hash of each key is stored.
+CREATE TABLE IF NOT EXISTS ai_api_keys (
+  key_hash CHAR(64) NOT NULL PRIMARY KEY,
+  usuario VARCHAR(64) NOT NULL,
+  nombre VARCHAR(128) NOT NULL DEFAULT '',
+  sucursal VARCHAR(64) NOT NULL DEFAULT '',
+  rol VARCHAR(32) NOT NULL DEFAULT 'ventas',
+  activo BOOLEAN NOT NULL DEFAULT TRUE,
+  creado TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
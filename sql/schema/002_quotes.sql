@@ -0,0 +1,31 @@
+-- Quotes saved by the agent with guardarCotizacion. The figures are computed by the
+-- server from the grupos prices, and stop being valid at vence because those prices change.
+CREATE TABLE IF NOT EXISTS ai_quotes (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  cliente VARCHAR(128) NOT NULL,
+  notas VARCHAR(512) NOT NULL DEFAULT '',
+  usuario VARCHAR(64) NOT NULL,
+  sucursal VARCHAR(64) NOT NULL DEFAULT '',
+  subtotal DOUBLE NOT NULL,
+  iva DOUBLE NOT NULL,
+  total DOUBLE NOT NULL,
+  creada DATETIME NOT NULL,
+  vence DATETIME NOT NULL,
+  INDEX idx_ai_quotes_usuario (usuario, creada)
+);
+
+CREATE TABLE IF NOT EXISTS ai_quote_lines (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  quote_id BIGINT NOT NULL,
+  codigo VARCHAR(32) NOT NULL,
+  descripcion VARCHAR(255) NOT NULL,
+  cantidad DOUBLE NOT NULL,
+  unidad VARCHAR(16) NOT NULL,
+  kg DOUBLE NOT NULL,
+  lista VARCHAR(32) NOT NULL,
+  precio_kg DOUBLE NOT NULL,
+  importe DOUBLE NOT NULL,
+  tasa_iva DOUBLE NOT NULL,
+  iva DOUBLE NOT NULL,
+  FOREIGN KEY (quote_id) REFERENCES ai_quotes (id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Saved quotes with prices out of the pricing policy wait here until a manager approves or
+-- rejects them. notificada is set once the rep was told the decision in the chat.
+CREATE TABLE IF NOT EXISTS ai_quote_approvals (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  quote_id BIGINT NOT NULL,
+  usuario VARCHAR(64) NOT NULL,
+  motivos VARCHAR(2048) NOT NULL,
+  estado VARCHAR(16) NOT NULL DEFAULT 'pendiente',
+  revisor VARCHAR(64) NOT NULL DEFAULT '',
+  comentario VARCHAR(512) NOT NULL DEFAULT '',
+  creada DATETIME NOT NULL,
+  resuelta DATETIME NULL,
+  notificada BOOLEAN NOT NULL DEFAULT FALSE,
+  INDEX idx_ai_quote_approvals_estado (estado, creada),
+  INDEX idx_ai_quote_approvals_usuario (usuario, notificada),
+  FOREIGN KEY (quote_id) REFERENCES ai_quotes (id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Stock held for a customer by a rep until vence. Active holds (not released and not expired)
+-- are taken from articulos.vexiact to get the stock that can still be promised.
+CREATE TABLE IF NOT EXISTS ai_stock_reservations (
+  id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  codigo VARCHAR(32) NOT NULL,
+  descripcion VARCHAR(255) NOT NULL,
+  kg DOUBLE NOT NULL,
+  cliente VARCHAR(128) NOT NULL,
+  numero_cliente VARCHAR(32) NOT NULL DEFAULT '',
+  usuario VARCHAR(64) NOT NULL,
+  sucursal VARCHAR(64) NOT NULL DEFAULT '',
+  creada DATETIME NOT NULL,
+  vence DATETIME NOT NULL,
+  liberada DATETIME NULL,
+  INDEX idx_ai_stock_reservations_codigo (codigo, vence),
+  INDEX idx_ai_stock_reservations_usuario (usuario, vence)
+);
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// sseWriter sends chat.completion.chunk events to the client as Server-Sent Events
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	id      string
+	model   string
+	created int64
+	// First error writing to the client, the answer may not have been delivered
+	err error
+}
+
+func newSSEWriter(w http.ResponseWriter, id, model string, created int64) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("response writer does not support flushing")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &sseWriter{
+		w:       w,
+		flusher: flusher,
+		id:      id,
+		model:   model,
+		created: created,
+	}, nil
+}
+
+func (s *sseWriter) writeChunk(delta OpenAIDelta, finishReason *string) {
+	s.writeEvent(OpenAIChunk{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: []OpenAIChunkChoice{
+			{
+				Index:        0,
+				Delta:        delta,
+				FinishReason: finishReason,
+			},
+		},
+	})
+}
+
+func (s *sseWriter) writeEvent(chunk OpenAIChunk) {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		log.Printf("failed to marshal chunk: %v\n", err)
+		return
+	}
+	s.write("data: %s\n\n", data)
+}
+
+// write sends a line to the client and keeps the first error
+func (s *sseWriter) write(format string, args ...any) {
+	if _, err := fmt.Fprintf(s.w, format, args...); err != nil && s.err == nil {
+		s.err = err
+	}
+	s.flusher.Flush()
+}
+
+// failed returns the first error writing to the client
+func (s *sseWriter) failed() error {
+	return s.err
+}
+
+// writeText sends a content delta
+func (s *sseWriter) writeText(text string) {
+	if text == "" {
+		return
+	}
+	s.writeChunk(OpenAIDelta{Content: text}, nil)
+}
+
+// writeComment sends an SSE comment line, ignored by clients but keeps the connection active
+func (s *sseWriter) writeComment(comment string) {
+	s.write(": %s\n\n", comment)
+}
+
+// finish sends the final chunk with the finish reason followed by the [DONE] marker.
+// When usage is not nil, an extra chunk with empty choices carries the token usage,
+// as OpenAI does for stream_options.include_usage.
+func (s *sseWriter) finish(finishReason string, usage *OpenAIUsage) {
+	s.writeChunk(OpenAIDelta{}, &finishReason)
+	if usage != nil {
+		s.writeEvent(OpenAIChunk{
+			ID:      s.id,
+			Object:  "chat.completion.chunk",
+			Created: s.created,
+			Model:   s.model,
+			Choices: []OpenAIChunkChoice{},
+			Usage:   usage,
+		})
+	}
+	s.write("data: [DONE]\n\n")
+}
+
+// writeError sends an OpenAI-style error object followed by the [DONE] marker.
+// Used when the stream has already started and the HTTP status can no longer change.
+func (s *sseWriter) writeError(openAIErr OpenAIError) {
+	data, err := json.Marshal(OpenAIErrorResponse{Error: openAIErr})
+	if err != nil {
+		log.Printf("failed to marshal error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(s.w, "data: %s\n\n", data)
+	fmt.Fprint(s.w, "data: [DONE]\n\n")
+	s.flusher.Flush()
+}
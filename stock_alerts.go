@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"fmt"
+	"strings"
+)
+
+// Kinds of stock alert, as the model asks for them
+const (
+	stockAlertLow  = "bajo"
+	stockAlertOver = "exceso"
+)
+
+type stockAlertsArgs struct {
+	Tipo  string `json:"tipo,omitempty" enum:"bajo,exceso" desc:"bajo para los productos debajo de su mínimo, exceso para los que pasan su máximo. Si no se indica se devuelven ambos."`
+	Linea string `json:"linea,omitempty" desc:"Parte del nombre de la línea para filtrar, por ejemplo 'pollo'."`
+}
+
+// stockAlert is a product whose stock (Vexiact) is below its minimum (Veximin) or above its
+// maximum (Veximax). Products without a minimum or a maximum in the ERP are never flagged for it.
+// ExistenciaFisicaKg is the stock in the warehouse, the kg reserved for customers included.
+type stockAlert struct {
+	Codigo             string
+	Descripcion        string
+	Marca              string
+	ExistenciaFisicaKg float64
+	MinimoKg           float64
+	MaximoKg           float64
+	// Vexidis, the stock that is not committed in the ERP
+	DisponibleKg float64
+	// kg missing to reach the minimum, or kg above the maximum
+	DiferenciaKg float64
+}
+
+type stockAlertGroup struct {
+	Linea       string
+	BajoMinimo  []stockAlert
+	SobreMaximo []stockAlert
+}
+
+func getStockAlerts(ctx context.Context, queries *database.Queries, args stockAlertsArgs) (any, error) {
+	groups, err := loadStockAlerts(ctx, queries)
+	if err != nil {
+		return nil, executionError("ocurrió un error al revisar las existencias", err)
+	}
+
+	linea := strings.ToUpper(strings.TrimSpace(args.Linea))
+	filtered := []stockAlertGroup{}
+	for _, group := range groups {
+		if linea != "" && !strings.Contains(strings.ToUpper(group.Linea), linea) {
+			continue
+		}
+		switch args.Tipo {
+		case stockAlertLow:
+			group.SobreMaximo = nil
+		case stockAlertOver:
+			group.BajoMinimo = nil
+		}
+		if len(group.BajoMinimo) > 0 || len(group.SobreMaximo) > 0 {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered, nil
+}
+
+// loadStockAlerts returns the products below their minimum or above their maximum, grouped
+// by line in the order of GetStockAlerts
+func loadStockAlerts(ctx context.Context, queries *database.Queries) ([]stockAlertGroup, error) {
+	rows, err := queries.GetStockAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []stockAlertGroup
+	for _, row := range rows {
+		if len(groups) == 0 || groups[len(groups)-1].Linea != row.Linea {
+			groups = append(groups, stockAlertGroup{Linea: row.Linea})
+		}
+		group := &groups[len(groups)-1]
+
+		alert := stockAlert{
+			Codigo:             row.Codigo,
+			Descripcion:        row.Descripcion,
+			Marca:              row.Marca,
+			ExistenciaFisicaKg: roundCents(row.ExistenciaKg),
+			MinimoKg:           roundCents(row.MinimoKg),
+			MaximoKg:           roundCents(row.MaximoKg),
+			DisponibleKg:       roundCents(row.DisponibleKg),
+		}
+		if row.MinimoKg > 0 && row.ExistenciaKg < row.MinimoKg {
+			alert.DiferenciaKg = roundCents(row.MinimoKg - row.ExistenciaKg)
+			group.BajoMinimo = append(group.BajoMinimo, alert)
+		} else {
+			alert.DiferenciaKg = roundCents(row.ExistenciaKg - row.MaximoKg)
+			group.SobreMaximo = append(group.SobreMaximo, alert)
+		}
+	}
+	return groups, nil
+}
+
+// stockWarning tells the rep that a quoted product is about to run out. ExistenciaKg is the
+// stock that can be promised, without the kg reserved for other customers.
+type stockWarning struct {
+	Codigo       string
+	Descripcion  string
+	ExistenciaKg float64
+	KgCotizados  float64
+	MinimoKg     float64
+	Aviso        string
+}
+
+// stockWarnings checks the kg quoted of every product against its stock, and warns when the
+// stock does not cover them or would be left below the minimum
+func stockWarnings(ctx context.Context, queries *database.Queries, lines []quoteLine) ([]stockWarning, error) {
+	var codes []string
+	quoted := map[string]float64{}
+	for _, line := range lines {
+		if _, ok := quoted[line.Codigo]; !ok {
+			codes = append(codes, line.Codigo)
+		}
+		quoted[line.Codigo] += line.Kg
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	rows, err := queries.GetProductStockLevels(ctx, codes)
+	if err != nil {
+		return nil, executionError("ocurrió un error al revisar las existencias", err)
+	}
+	holds, err := activeHolds(ctx, queries, codes)
+	if err != nil {
+		return nil, err
+	}
+	levels := map[string]database.GetProductStockLevelsRow{}
+	for _, row := range rows {
+		row.ExistenciaKg = availableToPromise(row.ExistenciaKg, holds[row.Codigo])
+		levels[row.Codigo] = row
+	}
+
+	var warnings []stockWarning
+	for _, code := range codes {
+		level, ok := levels[code]
+		if !ok {
+			continue
+		}
+		kg := roundCents(quoted[code])
+		left := roundCents(level.ExistenciaKg - kg)
+
+		var notice string
+		switch {
+		case left < 0:
+			notice = fmt.Sprintf("la existencia disponible de %.2f kg no alcanza para los %.2f kg cotizados", level.ExistenciaKg, kg)
+		case level.MinimoKg > 0 && left < level.MinimoKg:
+			notice = fmt.Sprintf("quedarían %.2f kg, debajo del mínimo de %.2f kg, está por agotarse", left, level.MinimoKg)
+		default:
+			continue
+		}
+		warnings = append(warnings, stockWarning{
+			Codigo:       level.Codigo,
+			Descripcion:  level.Descripcion,
+			ExistenciaKg: roundCents(level.ExistenciaKg),
+			KgCotizados:  kg,
+			MinimoKg:     roundCents(level.MinimoKg),
+			Aviso:        notice,
+		})
+	}
+	return warnings, nil
+}
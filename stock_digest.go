@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// parseDigestTime reads the time of day of the stock digest, e.g. "07:00", as the time since midnight
+func parseDigestTime(value string) (time.Duration, error) {
+	at, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid STOCK_DIGEST_AT %q, use HH:MM: %w", value, err)
+	}
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// nextDigestTime returns the next time the digest is due, today or tomorrow in the local time of now
+func nextDigestTime(now time.Time, at time.Duration) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	next := midnight.Add(at)
+	if !next.After(now) {
+		next = midnight.AddDate(0, 0, 1).Add(at)
+	}
+	return next
+}
+
+// runStockDigests sends the stock digest every day at STOCK_DIGEST_AT until ctx is done.
+// It does nothing when the digest is disabled.
+func (app *App) runStockDigests(ctx context.Context) {
+	if app.config.StockDigestAt == "" {
+		return
+	}
+	at, err := parseDigestTime(app.config.StockDigestAt)
+	if err != nil {
+		log.Printf("stock digest disabled: %v\n", err)
+		return
+	}
+
+	for {
+		now := timeNow()
+		next := nextDigestTime(now, at)
+		log.Printf("next stock digest at %s...\n", next.Format(time.DateTime))
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := app.sendStockDigest(ctx); err != nil {
+			log.Printf("failed to send stock digest: %v\n", err)
+		}
+	}
+}
+
+// sendStockDigest sends the products below their minimum or above their maximum through the
+// notifier. Nothing is sent on a day without alerts.
+func (app *App) sendStockDigest(ctx context.Context) error {
+	dbCtx, cancel := context.WithTimeout(ctx, app.config.DBQueryTimeout)
+	defer cancel()
+
+	groups, err := loadStockAlerts(dbCtx, app.queries)
+	if err != nil {
+		return fmt.Errorf("failed to load stock alerts: %w", err)
+	}
+	if len(groups) == 0 {
+		log.Printf("no stock alerts today, skipping digest...\n")
+		return nil
+	}
+
+	now := timeNow()
+	title := "Alertas de inventario " + now.Format("02/01/2006")
+	return app.notifier.Notify(ctx, notification{
+		Kind:  "stock_digest",
+		Title: title,
+		Text:  stockDigestText(title, groups),
+		Time:  now,
+		Data:  groups,
+	})
+}
+
+// stockDigestText writes the alerts by line, ready to read in a chat
+func stockDigestText(title string, groups []stockAlertGroup) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*%s*\n", title)
+	for _, group := range groups {
+		fmt.Fprintf(&text, "\n*%s*\n", group.Linea)
+		for _, alert := range group.BajoMinimo {
+			fmt.Fprintf(&text, "⚠️ %s %s: %.2f kg, mínimo %.2f kg (faltan %.2f kg)\n",
+				alert.Codigo, alert.Descripcion, alert.ExistenciaFisicaKg, alert.MinimoKg, alert.DiferenciaKg)
+		}
+		for _, alert := range group.SobreMaximo {
+			fmt.Fprintf(&text, "📦 %s %s: %.2f kg, máximo %.2f kg (sobran %.2f kg)\n",
+				alert.Codigo, alert.Descripcion, alert.ExistenciaFisicaKg, alert.MaximoKg, alert.DiferenciaKg)
+		}
+	}
+	return text.String()
+}
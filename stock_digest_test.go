@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNextDigestTime(t *testing.T) {
+	at := 7 * time.Hour
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2025, 3, 10, 6, 59, 0, 0, time.UTC), time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC), time.Date(2025, 3, 11, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 31, 23, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := nextDigestTime(tt.now, at); !got.Equal(tt.want) {
+			t.Errorf("nextDigestTime(%s) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
+
+// The digest groups the alerts by line and reaches the webhook as JSON
+func TestStockDigestWebhook(t *testing.T) {
+	fixClock(t, testNow)
+
+	received := make(chan notification, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- n
+	}))
+	t.Cleanup(hook.Close)
+
+	db := newFakeDB(testCatalog)
+	t.Cleanup(func() { db.Close() })
+	notifier, err := newNotifier(Config{Notifier: "webhook", NotifierWebhookURL: hook.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &App{
+		config:   Config{DBQueryTimeout: 5 * time.Second},
+		queries:  database.New(db),
+		notifier: notifier,
+	}
+
+	if err := app.sendStockDigest(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	n := <-received
+	if n.Kind != "stock_digest" || n.Title != "Alertas de inventario 10/03/2025" {
+		t.Errorf("unexpected notification %q %q", n.Kind, n.Title)
+	}
+	assertGolden(t, "stock_digest", []byte(n.Text))
+}
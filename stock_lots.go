@@ -0,0 +1,340 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"copo-ai-agent/internal/database"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entries older than lotHistoryDays are taken as sold when the lots on hand are rebuilt
+const lotHistoryDays = 180
+
+// Days ahead read by sugerirRemate when the model does not ask for a period
+const defaultSelloffDays = 7
+
+// Stock expiring within selloffWholesaleDays is offered at the mayoreo price for any quantity,
+// and within selloffDiscountDays also with selloffDiscountPct off mayoreo
+const (
+	selloffWholesaleDays = 5
+	selloffDiscountDays  = 2
+	selloffDiscountPct   = 10
+)
+
+// expiryRange is a bucket of stock that expires in at most maxDays
+type expiryRange struct {
+	label   string
+	maxDays int
+}
+
+// Expiry buckets, from the expired stock to the stock with the longest shelf life
+var expiryBuckets = []expiryRange{
+	{"vencido", -1},
+	{"0 a 3 días", 3},
+	{"4 a 7 días", 7},
+	{"8 a 15 días", 15},
+	{"16 a 30 días", 30},
+	{"más de 30 días", math.MaxInt},
+}
+
+// Bucket of the stock without an expiry date in its entry
+const expiryUnknown = "sin caducidad"
+
+type stockExpiryArgs struct {
+	ProductCodes []string `json:"productCodes" desc:"Códigos de los productos."`
+}
+
+type selloffArgs struct {
+	Dias  int    `json:"dias,omitempty" desc:"Días hacia adelante para buscar existencia por caducar, 7 si no se indica."`
+	Linea string `json:"linea,omitempty" desc:"Parte del nombre de la línea para filtrar, por ejemplo 'pollo'."`
+}
+
+// stockLot is the part of an entry (ent01 in movimientosd) that is still on hand. Caducidad
+// is empty when the entry has no expiry date (Vcaduci), and Folio is 0 for the stock that
+// no entry of the last lotHistoryDays explains.
+type stockLot struct {
+	Folio         int32
+	Entrada       string
+	Caducidad     string
+	DiasRestantes int
+	Kg            float64
+}
+
+type expiryBucket struct {
+	Rango string
+	Kg    float64
+}
+
+// productExpiry is the stock of a product in the warehouse (Vexiact, reserved kg included) by
+// expiry date
+type productExpiry struct {
+	Codigo             string
+	Descripcion        string
+	ExistenciaFisicaKg float64
+	Rangos             []expiryBucket
+	Lotes              []stockLot
+}
+
+// selloffSuggestion is a product with stock expiring soon and the price level suggested to move it.
+// ExistenciaFisicaKg is Vexiact, the kg reserved for customers included.
+type selloffSuggestion struct {
+	Codigo             string
+	Descripcion        string
+	Linea              string
+	ExistenciaFisicaKg float64
+	// kg that expire within the days asked, and the nearest of those expiry dates
+	KgPorCaducar        float64
+	CaducidadMasProxima string
+	DiasRestantes       int
+	// Expired kg, to take off the shelf instead of selling them
+	KgVencidos       float64
+	NivelSugerido    string
+	PrecioSugeridoKg float64
+	// The suggested price breaks the pricing policy, a quote with it waits for a manager
+	RequiereAprobacion bool
+	Motivo             string
+}
+
+func getStockByExpiry(ctx context.Context, queries *database.Queries, args stockExpiryArgs) (any, error) {
+	if len(args.ProductCodes) == 0 {
+		return nil, invalidArgumentError("productCodes debe incluir al menos un código")
+	}
+	var codes []string
+	for _, code := range args.ProductCodes {
+		if code = strings.TrimSpace(code); code != "" && !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+
+	rows, err := queries.GetProductStockLevels(ctx, codes)
+	if err != nil {
+		return nil, executionError("ocurrió un error al revisar la existencia", err)
+	}
+	levels := map[string]database.GetProductStockLevelsRow{}
+	stock := map[string]float64{}
+	for _, row := range rows {
+		levels[row.Codigo] = row
+		stock[row.Codigo] = row.ExistenciaKg
+	}
+	for _, code := range codes {
+		if _, ok := levels[code]; !ok {
+			return nil, invalidArgumentError("no se encontró el código %s", code)
+		}
+	}
+	lots, err := loadStockLots(ctx, queries, stock)
+	if err != nil {
+		return nil, err
+	}
+
+	products := []productExpiry{}
+	for _, code := range codes {
+		level := levels[code]
+		products = append(products, productExpiry{
+			Codigo:             level.Codigo,
+			Descripcion:        level.Descripcion,
+			ExistenciaFisicaKg: roundCents(level.ExistenciaKg),
+			Rangos:             bucketLots(lots[code]),
+			Lotes:              lots[code],
+		})
+	}
+	return products, nil
+}
+
+// suggestSelloff returns the sugerirRemate tool function. The suggested prices are checked
+// against the pricing policy so the rep knows which ones need a manager.
+func suggestSelloff(policy pricingPolicy) func(context.Context, *database.Queries, selloffArgs) (any, error) {
+	return func(ctx context.Context, queries *database.Queries, args selloffArgs) (any, error) {
+		if args.Dias < 0 {
+			return nil, invalidArgumentError("dias debe ser mayor a cero")
+		}
+		days := cmp.Or(args.Dias, defaultSelloffDays)
+
+		today := timeNow()
+		products, err := queries.GetProductsWithExpiringLots(ctx, database.GetProductsWithExpiringLotsParams{
+			Desde: today.AddDate(0, 0, -lotHistoryDays).Format(time.DateOnly),
+			Hasta: today.AddDate(0, 0, days),
+		})
+		if err != nil {
+			return nil, executionError("ocurrió un error al revisar las caducidades", err)
+		}
+		linea := strings.ToUpper(strings.TrimSpace(args.Linea))
+		stock := map[string]float64{}
+		for _, product := range products {
+			if linea == "" || strings.Contains(strings.ToUpper(product.Linea), linea) {
+				stock[product.Codigo] = product.ExistenciaKg
+			}
+		}
+		lots, err := loadStockLots(ctx, queries, stock)
+		if err != nil {
+			return nil, err
+		}
+
+		var suggestions, expired []selloffSuggestion
+		var proposed []policyPrice
+		for _, product := range products {
+			if _, ok := stock[product.Codigo]; !ok {
+				continue
+			}
+			suggestion := selloffSuggestion{
+				Codigo:             product.Codigo,
+				Descripcion:        product.Descripcion,
+				Linea:              product.Linea,
+				ExistenciaFisicaKg: roundCents(product.ExistenciaKg),
+				DiasRestantes:      math.MaxInt,
+			}
+			for _, lot := range lots[product.Codigo] {
+				switch {
+				case lot.Caducidad == "" || lot.DiasRestantes > days:
+				case lot.DiasRestantes < 0:
+					suggestion.KgVencidos = roundCents(suggestion.KgVencidos + lot.Kg)
+				default:
+					suggestion.KgPorCaducar = roundCents(suggestion.KgPorCaducar + lot.Kg)
+					if lot.DiasRestantes < suggestion.DiasRestantes {
+						suggestion.DiasRestantes = lot.DiasRestantes
+						suggestion.CaducidadMasProxima = lot.Caducidad
+					}
+				}
+			}
+			if suggestion.KgPorCaducar == 0 {
+				// Only expired stock, or entries with an early expiry date that were sold already
+				if suggestion.KgVencidos > 0 {
+					suggestion.DiasRestantes = 0
+					expired = append(expired, suggestion)
+				}
+				continue
+			}
+			var priced bool
+			suggestion.NivelSugerido, suggestion.PrecioSugeridoKg, priced = selloffPrice(product, suggestion.DiasRestantes)
+			if priced {
+				proposed = append(proposed, policyPrice{Codigo: product.Codigo, Precio: suggestion.PrecioSugeridoKg})
+			} else {
+				suggestion.RequiereAprobacion = true
+				suggestion.Motivo = "el producto no tiene precio registrado, gerencia debe fijar el precio de remate"
+			}
+			suggestions = append(suggestions, suggestion)
+		}
+
+		violations, err := policy.check(ctx, queries, proposed)
+		if err != nil {
+			return nil, err
+		}
+		for _, violation := range violations {
+			i := slices.IndexFunc(suggestions, func(s selloffSuggestion) bool { return s.Codigo == violation.Codigo })
+			suggestions[i].RequiereAprobacion = true
+			suggestions[i].Motivo = violation.Motivo
+		}
+
+		// The nearest expiry first, then the products that only have expired stock
+		slices.SortStableFunc(suggestions, func(a, b selloffSuggestion) int {
+			return cmp.Or(cmp.Compare(a.DiasRestantes, b.DiasRestantes), cmp.Compare(b.KgPorCaducar, a.KgPorCaducar))
+		})
+		return append(append([]selloffSuggestion{}, suggestions...), expired...), nil
+	}
+}
+
+// selloffPrice suggests the price level for stock expiring in the given days: medio mayoreo
+// for any quantity, mayoreo when it is close and a discount off that level when it is about to
+// expire. A level without price falls back to mayoreo or medio mayoreo, and then to detalle.
+// It returns false when the product has no price at all.
+func selloffPrice(product database.GetProductsWithExpiringLotsRow, days int) (string, float64, bool) {
+	type level struct {
+		list  string
+		price float64
+	}
+	levels := []level{
+		{priceListHalfSale, product.PrecioMedioMayoreo},
+		{priceListWholesale, product.PrecioMayoreo},
+		{priceListRetail, product.PrecioDetalle},
+	}
+	if days <= selloffWholesaleDays {
+		levels[0], levels[1] = levels[1], levels[0]
+	}
+	i := slices.IndexFunc(levels, func(l level) bool { return l.price > 0 })
+	if i < 0 {
+		return "", 0, false
+	}
+	if days <= selloffDiscountDays {
+		return fmt.Sprintf("%s con %d%% de descuento", levels[i].list, selloffDiscountPct),
+			roundCents(levels[i].price * (100 - selloffDiscountPct) / 100), true
+	}
+	return levels[i].list, levels[i].price, true
+}
+
+// loadStockLots rebuilds the lots on hand of every product from its recent entries. Stock is
+// sold first in first out, so the stock on hand is the newest entries that add up to it.
+func loadStockLots(ctx context.Context, queries *database.Queries, stock map[string]float64) (map[string][]stockLot, error) {
+	lots := map[string][]stockLot{}
+	if len(stock) == 0 {
+		return lots, nil
+	}
+	var codes []string
+	for code := range stock {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	today := timeNow()
+	entries, err := queries.GetProductInboundLots(ctx, database.GetProductInboundLotsParams{
+		Desde:        today.AddDate(0, 0, -lotHistoryDays).Format(time.DateOnly),
+		ProductCodes: codes,
+	})
+	if err != nil {
+		return nil, executionError("ocurrió un error al obtener las entradas de los productos", err)
+	}
+
+	left := map[string]float64{}
+	for code, kg := range stock {
+		left[code] = roundCents(kg)
+	}
+	for _, entry := range entries {
+		if left[entry.Codigo] <= 0 {
+			continue
+		}
+		lot := stockLot{
+			Folio:   entry.Folio,
+			Entrada: entry.Fecha,
+			Kg:      roundCents(min(entry.Kg, left[entry.Codigo])),
+		}
+		// Entries without an expiry date hold a zero date in Vcaduci
+		if entry.Caducidad.Year() > 2000 {
+			lot.Caducidad = entry.Caducidad.Format(time.DateOnly)
+			lot.DiasRestantes = int(daysBetween(today.Format(time.DateOnly), lot.Caducidad))
+		}
+		lots[entry.Codigo] = append(lots[entry.Codigo], lot)
+		left[entry.Codigo] = roundCents(left[entry.Codigo] - lot.Kg)
+	}
+	for _, code := range codes {
+		if left[code] > 0 {
+			lots[code] = append(lots[code], stockLot{Kg: left[code]})
+		}
+	}
+	return lots, nil
+}
+
+// bucketLots adds up the kg of the lots by expiry bucket, skipping the empty buckets
+func bucketLots(lots []stockLot) []expiryBucket {
+	kg := map[string]float64{}
+	for _, lot := range lots {
+		label := expiryUnknown
+		if lot.Caducidad != "" {
+			i := slices.IndexFunc(expiryBuckets, func(b expiryRange) bool { return lot.DiasRestantes <= b.maxDays })
+			label = expiryBuckets[i].label
+		}
+		kg[label] = roundCents(kg[label] + lot.Kg)
+	}
+
+	buckets := []expiryBucket{}
+	for _, b := range expiryBuckets {
+		if kg[b.label] > 0 {
+			buckets = append(buckets, expiryBucket{Rango: b.label, Kg: kg[b.label]})
+		}
+	}
+	if kg[expiryUnknown] > 0 {
+		buckets = append(buckets, expiryBucket{Rango: expiryUnknown, Kg: kg[expiryUnknown]})
+	}
+	return buckets
+}
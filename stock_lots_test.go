@@ -0,0 +1,44 @@
+package main
+
+import (
+	"copo-ai-agent/internal/database"
+	"testing"
+)
+
+func TestSelloffPrice(t *testing.T) {
+	product := database.GetProductsWithExpiringLotsRow{Codigo: "101", PrecioDetalle: 95.5, PrecioMedioMayoreo: 92, PrecioMayoreo: 89}
+	tests := []struct {
+		name       string
+		change     func(p *database.GetProductsWithExpiringLotsRow)
+		days       int
+		wantLevel  string
+		wantPrice  float64
+		wantPriced bool
+	}{
+		{"discount off mayoreo", nil, 2, "mayoreo con 10% de descuento", 80.1, true},
+		{"mayoreo", nil, 5, priceListWholesale, 89, true},
+		{"medio mayoreo", nil, 6, priceListHalfSale, 92, true},
+		{"discount without mayoreo", func(p *database.GetProductsWithExpiringLotsRow) { p.PrecioMayoreo = 0 }, 1,
+			"medio mayoreo con 10% de descuento", 82.8, true},
+		{"mayoreo without price", func(p *database.GetProductsWithExpiringLotsRow) { p.PrecioMayoreo = 0 }, 4, priceListHalfSale, 92, true},
+		{"medio mayoreo without price", func(p *database.GetProductsWithExpiringLotsRow) { p.PrecioMedioMayoreo = 0 }, 6, priceListWholesale, 89, true},
+		{"only detalle", func(p *database.GetProductsWithExpiringLotsRow) {
+			p.PrecioMedioMayoreo, p.PrecioMayoreo = 0, 0
+		}, 4, priceListRetail, 95.5, true},
+		{"no price", func(p *database.GetProductsWithExpiringLotsRow) {
+			p.PrecioDetalle, p.PrecioMedioMayoreo, p.PrecioMayoreo = 0, 0, 0
+		}, 1, "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := product
+			if tt.change != nil {
+				tt.change(&p)
+			}
+			level, price, priced := selloffPrice(p, tt.days)
+			if level != tt.wantLevel || price != tt.wantPrice || priced != tt.wantPriced {
+				t.Errorf("selloffPrice(%d days) = %q %v %v, want %q %v %v", tt.days, level, price, priced, tt.wantLevel, tt.wantPrice, tt.wantPriced)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"copo-ai-agent/internal/database"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// newFunctionTool registers a tool whose arguments are the fields of the struct A.
+// The declaration schema is built from the struct and the arguments of every call are
+// validated and decoded into it before fn runs, so tools never see map[string]any.
+//
+// Each field is described with tags:
+//
+//	json:"name"           argument name, ",omitempty" makes it optional
+//	desc:"..."            description for the model
+//	enum:"a,b,c"          allowed values of a string argument
+//
+// Supported field types are string, bool, integers, floats, nested argument structs and slices of them.
+func newFunctionTool[A any](name, description string, fn func(context.Context, *database.Queries, A) (any, error)) FunctionTool {
+	return FunctionTool{
+		Name: name,
+		Declaration: &ToolDeclaration{
+			Name:        name,
+			Description: description,
+			Parameters:  argsSchema(reflect.TypeFor[A]()),
+		},
+		Function: func(ctx context.Context, queries *database.Queries, args map[string]any) (any, error) {
+			decoded, err := decodeToolArgs[A](args)
+			if err != nil {
+				return nil, err
+			}
+			return fn(ctx, queries, decoded)
+		},
+	}
+}
+
+// noArgs is the argument struct of the tools without arguments
+type noArgs struct{}
+
+// toolArgField is an argument described by a struct field
+type toolArgField struct {
+	index       int
+	name        string
+	description string
+	enum        []string
+	optional    bool
+}
+
+func toolArgFields(t reflect.Type) []toolArgField {
+	var fields []toolArgField
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		arg := toolArgField{
+			index:       i,
+			name:        name,
+			description: field.Tag.Get("desc"),
+			optional:    slices.Contains(strings.Split(options, ","), "omitempty"),
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			arg.enum = strings.Split(enum, ",")
+		}
+		fields = append(fields, arg)
+	}
+	return fields
+}
+
+// argsSchema builds the object schema of an argument struct. Only text arguments can have an enum.
+func argsSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: schemaObject}
+	for _, field := range toolArgFields(t) {
+		if len(field.enum) > 0 && t.Field(field.index).Type.Kind() != reflect.String {
+			panic(fmt.Sprintf("enum on tool argument %s of type %s, only strings can have an enum", field.name, t.Field(field.index).Type))
+		}
+		property := typeSchema(t.Field(field.index).Type)
+		property.Description = field.description
+		property.Enum = field.enum
+
+		if schema.Properties == nil {
+			schema.Properties = map[string]*Schema{}
+		}
+		schema.Properties[field.name] = property
+		if !field.optional {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+	return schema
+}
+
+func typeSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: schemaString}
+	case reflect.Bool:
+		return &Schema{Type: schemaBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: schemaInteger}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: schemaNumber}
+	case reflect.Slice:
+		return &Schema{Type: schemaArray, Items: typeSchema(t.Elem())}
+	case reflect.Struct:
+		return argsSchema(t)
+	}
+	panic(fmt.Sprintf("unsupported tool argument type %s", t))
+}
+
+// decodeToolArgs validates the arguments of a call against the struct A and decodes them.
+// The errors name the exact argument and element at fault, so the model can fix the call.
+func decodeToolArgs[A any](args map[string]any) (A, error) {
+	var decoded A
+	err := decodeArgStruct(reflect.ValueOf(&decoded).Elem(), args, "")
+	return decoded, err
+}
+
+// decodeArgStruct sets the fields of dst from an object argument.
+// path is the location of the object, e.g. "productos[0].", empty for the top level.
+func decodeArgStruct(dst reflect.Value, args map[string]any, path string) error {
+	fields := toolArgFields(dst.Type())
+
+	for name := range args {
+		if !slices.ContainsFunc(fields, func(f toolArgField) bool { return f.name == name }) {
+			return invalidArgumentError("argumento desconocido %s%s", path, name)
+		}
+	}
+
+	for _, field := range fields {
+		name := path + field.name
+		arg, ok := args[field.name]
+		if !ok || arg == nil {
+			if field.optional {
+				continue
+			}
+			return invalidArgumentError("falta el argumento %s", name)
+		}
+		if err := decodeArgValue(dst.Field(field.index), arg, name); err != nil {
+			return err
+		}
+		if len(field.enum) > 0 && !slices.Contains(field.enum, dst.Field(field.index).String()) {
+			return invalidArgumentError("el argumento %s debe ser uno de %s, se recibió %q",
+				name, strings.Join(field.enum, ", "), dst.Field(field.index).String())
+		}
+	}
+	return nil
+}
+
+// decodeArgValue sets dst from a JSON decoded value. name is the path of the argument in the errors.
+func decodeArgValue(dst reflect.Value, arg any, name string) error {
+	label := "el argumento " + name
+	switch dst.Kind() {
+	case reflect.String:
+		str, ok := arg.(string)
+		if !ok {
+			return invalidArgumentError("%s debe ser un texto, se recibió %T", label, arg)
+		}
+		dst.SetString(str)
+	case reflect.Bool:
+		b, ok := arg.(bool)
+		if !ok {
+			return invalidArgumentError("%s debe ser verdadero o falso, se recibió %T", label, arg)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := argNumber(arg)
+		if !ok || number != math.Trunc(number) {
+			return invalidArgumentError("%s debe ser un número entero, se recibió %v", label, arg)
+		}
+		if dst.OverflowInt(int64(number)) {
+			return invalidArgumentError("%s está fuera de rango: %v", label, arg)
+		}
+		dst.SetInt(int64(number))
+	case reflect.Float32, reflect.Float64:
+		number, ok := argNumber(arg)
+		if !ok {
+			return invalidArgumentError("%s debe ser un número, se recibió %T", label, arg)
+		}
+		dst.SetFloat(number)
+	case reflect.Slice:
+		items := reflect.ValueOf(arg)
+		if items.Kind() != reflect.Slice {
+			return invalidArgumentError("%s debe ser una lista, se recibió %T", label, arg)
+		}
+		list := reflect.MakeSlice(dst.Type(), items.Len(), items.Len())
+		for i := range items.Len() {
+			if err := decodeArgValue(list.Index(i), items.Index(i).Interface(), fmt.Sprintf("%s[%d]", name, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(list)
+	case reflect.Struct:
+		object, ok := arg.(map[string]any)
+		if !ok {
+			return invalidArgumentError("%s debe ser un objeto, se recibió %T", label, arg)
+		}
+		return decodeArgStruct(dst, object, name+".")
+	default:
+		return fmt.Errorf("unsupported tool argument type %s", dst.Type())
+	}
+	return nil
+}
+
+// argNumber accepts the float64 of decoded JSON as well as Go numbers passed directly
+func argNumber(arg any) (float64, bool) {
+	switch v := reflect.ValueOf(arg); v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	}
+	return 0, false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testToolArgs struct {
+	Codes    []string `json:"codes" desc:"Códigos"`
+	Kg       float64  `json:"kg"`
+	Cajas    int      `json:"cajas,omitempty"`
+	Lista    string   `json:"lista,omitempty" enum:"detalle,mayoreo"`
+	Internal string   `json:"-"`
+}
+
+func TestArgsSchema(t *testing.T) {
+	schema := argsSchema(reflect.TypeFor[testToolArgs]())
+
+	if want := []string{"codes", "kg"}; !reflect.DeepEqual(schema.Required, want) {
+		t.Errorf("required = %v, want %v", schema.Required, want)
+	}
+	if got := schema.Properties["codes"]; got.Type != schemaArray || got.Items.Type != schemaString || got.Description != "Códigos" {
+		t.Errorf("unexpected codes schema %+v", got)
+	}
+	if got := schema.Properties["cajas"].Type; got != schemaInteger {
+		t.Errorf("cajas type = %s, want integer", got)
+	}
+	if got := schema.Properties["lista"].Enum; !reflect.DeepEqual(got, []string{"detalle", "mayoreo"}) {
+		t.Errorf("lista enum = %v", got)
+	}
+	if _, ok := schema.Properties["Internal"]; ok {
+		t.Error("fields tagged json:\"-\" must not be arguments")
+	}
+}
+
+// An enum is only checked on text arguments, so any other type is rejected when the tool is registered
+func TestArgsSchemaRejectsEnumOnNumbers(t *testing.T) {
+	type enumArgs struct {
+		Dias int `json:"dias" enum:"7,15"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("argsSchema accepted an enum on an int argument")
+		}
+	}()
+	argsSchema(reflect.TypeFor[enumArgs]())
+}
+
+func TestDecodeToolArgs(t *testing.T) {
+	args, err := decodeToolArgs[testToolArgs](map[string]any{"codes": []any{"101"}, "kg": 12.5, "cajas": 3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (testToolArgs{Codes: []string{"101"}, Kg: 12.5, Cajas: 3}); !reflect.DeepEqual(args, want) {
+		t.Errorf("decoded %+v, want %+v", args, want)
+	}
+
+	tests := []struct {
+		args map[string]any
+		want string
+	}{
+		{map[string]any{"kg": 1.0}, "falta el argumento codes"},
+		{map[string]any{"codes": []any{"101", 7.0}, "kg": 1.0}, "el argumento codes[1] debe ser un texto, se recibió float64"},
+		{map[string]any{"codes": "101", "kg": 1.0}, "el argumento codes debe ser una lista, se recibió string"},
+		{map[string]any{"codes": []any{}, "kg": 1.0, "cajas": 1.5}, "el argumento cajas debe ser un número entero, se recibió 1.5"},
+		{map[string]any{"codes": []any{}, "kg": 1.0, "lista": "medio"}, `el argumento lista debe ser uno de detalle, mayoreo, se recibió "medio"`},
+		{map[string]any{"codes": []any{}, "kg": 1.0, "sucursal": "Tula"}, "argumento desconocido sucursal"},
+	}
+	for _, tt := range tests {
+		_, err := decodeToolArgs[testToolArgs](tt.args)
+		var toolErr *ToolError
+		if !errors.As(err, &toolErr) || toolErr.Code != toolErrInvalidArgument || toolErr.Message != tt.want {
+			t.Errorf("decode %v: got error %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
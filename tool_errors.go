@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Error codes sent back to the model inside the function response
+const (
+	toolErrUnknownTool     = "unknown_tool"
+	toolErrInvalidArgument = "invalid_argument"
+	toolErrExecution       = "execution_error"
+)
+
+// ToolError is a failure of a tool call. Message is written for the model,
+// while Err keeps the underlying cause for the logs.
+type ToolError struct {
+	Code      string
+	Message   string
+	Retryable bool
+	Err       error
+}
+
+func (e *ToolError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *ToolError) Unwrap() error {
+	return e.Err
+}
+
+func unknownToolError(name string) *ToolError {
+	return &ToolError{
+		Code:    toolErrUnknownTool,
+		Message: fmt.Sprintf("la función %q no existe, usa únicamente las funciones declaradas", name),
+	}
+}
+
+func invalidArgumentError(format string, args ...any) *ToolError {
+	return &ToolError{
+		Code:      toolErrInvalidArgument,
+		Message:   fmt.Sprintf(format, args...),
+		Retryable: true,
+	}
+}
+
+func executionError(message string, err error) *ToolError {
+	return &ToolError{
+		Code:      toolErrExecution,
+		Message:   message,
+		Retryable: true,
+		Err:       err,
+	}
+}
+
+// toolErrorPayload builds the structured error sent to the model in place of the result
+func toolErrorPayload(err error) map[string]any {
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) {
+		toolErr = executionError("ocurrió un error inesperado al ejecutar la función", err)
+	}
+	return map[string]any{
+		"error": map[string]any{
+			"code":      toolErr.Code,
+			"message":   toolErr.Message,
+			"retryable": toolErr.Retryable,
+		},
+	}
+}
+
+type toolCallStats struct {
+	Calls    int `json:"calls"`
+	Failures int `json:"failures"`
+}
+
+// toolStatsRegistry counts calls and failures per tool name
+type toolStatsRegistry struct {
+	mu    sync.Mutex
+	stats map[string]*toolCallStats
+}
+
+func newToolStatsRegistry() *toolStatsRegistry {
+	return &toolStatsRegistry{stats: map[string]*toolCallStats{}}
+}
+
+func (r *toolStatsRegistry) record(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.stats[name]
+	if !ok {
+		stats = &toolCallStats{}
+		r.stats[name] = stats
+	}
+	stats.Calls++
+	if err != nil {
+		stats.Failures++
+		log.Printf("tool %s failed (%d/%d failures): %v\n", name, stats.Failures, stats.Calls, err)
+	}
+}
+
+// snapshot returns a copy of the current counters
+func (r *toolStatsRegistry) snapshot() map[string]toolCallStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make(map[string]toolCallStats, len(r.stats))
+	for name, stats := range r.stats {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
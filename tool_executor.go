@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	// Maximum number of tools executed at the same time for a single turn
+	maxToolWorkers = 4
+	// Maximum number of function-call rounds before giving up on a request
+	maxToolRounds = 8
+)
+
+// executeToolCalls runs every tool call of a turn using a bounded pool of workers.
+// The results keep the order of the calls and carry the matching name and ID.
+// A failing or unknown tool answers with a structured error so the model can retry or apologize.
+func (app *App) executeToolCalls(ctx context.Context, tools CompletionTools, calls []ToolCall) []ToolResult {
+	results := make([]ToolResult, len(calls))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(maxToolWorkers, len(calls)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				call := calls[i]
+				result, err := app.callTool(ctx, tools, call.Name, call.Args)
+				app.toolStats.record(call.Name, err)
+
+				response := map[string]any{"result": result}
+				if err != nil {
+					response = toolErrorPayload(err)
+				}
+				results[i] = ToolResult{
+					CallID:   call.ID,
+					Name:     call.Name,
+					Response: response,
+				}
+			}
+		}()
+	}
+
+	for i := range calls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// callTool executes the named tool, failing with an unknown_tool error when it is not part of tools.
+// A panic inside the tool is reported as an execution error instead of crashing the handler.
+func (app *App) callTool(ctx context.Context, tools CompletionTools, name string, args map[string]any) (result any, err error) {
+	tool, ok := tools.getToolByName(name)
+	if !ok || tool.Function == nil {
+		return nil, unknownToolError(name)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = executionError("ocurrió un error inesperado al ejecutar la función", fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	return tool.Function(ctx, app.queries, args)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// tokenUsage adds up the Gemini usage metadata of every round of a request
+type tokenUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CandidatesTokens    int `json:"candidates_tokens"`
+	CachedTokens        int `json:"cached_tokens"`
+	ToolUsePromptTokens int `json:"tool_use_prompt_tokens"`
+	ThoughtsTokens      int `json:"thoughts_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+}
+
+func (u *tokenUsage) add(metadata *genai.GenerateContentResponseUsageMetadata) {
+	if metadata == nil {
+		return
+	}
+	u.PromptTokens += int(metadata.PromptTokenCount)
+	u.CandidatesTokens += int(metadata.CandidatesTokenCount)
+	u.CachedTokens += int(metadata.CachedContentTokenCount)
+	u.ToolUsePromptTokens += int(metadata.ToolUsePromptTokenCount)
+	u.ThoughtsTokens += int(metadata.ThoughtsTokenCount)
+	u.TotalTokens += int(metadata.TotalTokenCount)
+}
+
+func (u *tokenUsage) addUsage(other tokenUsage) {
+	u.PromptTokens += other.PromptTokens
+	u.CandidatesTokens += other.CandidatesTokens
+	u.CachedTokens += other.CachedTokens
+	u.ToolUsePromptTokens += other.ToolUsePromptTokens
+	u.ThoughtsTokens += other.ThoughtsTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// toOpenAI converts the usage to the OpenAI format. Tool-use prompt tokens count as prompt tokens
+// and thinking tokens as completion tokens, so the total matches what Gemini bills.
+func (u tokenUsage) toOpenAI() OpenAIUsage {
+	return OpenAIUsage{
+		PromptTokens:     u.PromptTokens + u.ToolUsePromptTokens,
+		CompletionTokens: u.CandidatesTokens + u.ThoughtsTokens,
+		TotalTokens:      u.TotalTokens,
+		PromptTokensDetails: &OpenAIPromptTokensDetails{
+			CachedTokens: u.CachedTokens,
+		},
+		CompletionTokensDetails: &OpenAICompletionTokensDetails{
+			ReasoningTokens: u.ThoughtsTokens,
+		},
+	}
+}
+
+// usageRecord is one line of the ledger file, written after every request
+type usageRecord struct {
+	Time  time.Time  `json:"time"`
+	User  string     `json:"user"`
+	Model string     `json:"model"`
+	Usage tokenUsage `json:"usage"`
+}
+
+// usageSummary is the usage of a user on a given day
+type usageSummary struct {
+	Date     string     `json:"date"`
+	User     string     `json:"user"`
+	Requests int        `json:"requests"`
+	Usage    tokenUsage `json:"usage"`
+}
+
+type usageKey struct {
+	date string
+	user string
+}
+
+// usageLedger keeps the token usage per user and per day. Every request is appended
+// to a JSONL file, which is read back on startup to rebuild the totals.
+type usageLedger struct {
+	mu     sync.Mutex
+	path   string
+	totals map[usageKey]*usageSummary
+}
+
+func newUsageLedger(path string) (*usageLedger, error) {
+	ledger := &usageLedger{
+		path:   path,
+		totals: map[usageKey]*usageSummary{},
+	}
+	if path == "" {
+		return ledger, nil
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ledger, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open usage ledger: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record usageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.Printf("skipping invalid usage ledger line: %v\n", err)
+			continue
+		}
+		ledger.addToTotals(record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+
+	return ledger, nil
+}
+
+func (l *usageLedger) addToTotals(record usageRecord) {
+	key := usageKey{date: record.Time.Format(time.DateOnly), user: record.User}
+	summary, ok := l.totals[key]
+	if !ok {
+		summary = &usageSummary{Date: key.date, User: key.user}
+		l.totals[key] = summary
+	}
+	summary.Requests++
+	summary.Usage.addUsage(record.Usage)
+}
+
+// record adds the usage of a request to the ledger
+func (l *usageLedger) record(user, model string, usage tokenUsage) {
+	if user == "" {
+		user = "anonymous"
+	}
+	record := usageRecord{
+		Time:  time.Now(),
+		User:  user,
+		Model: model,
+		Usage: usage,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.addToTotals(record)
+	if l.path == "" {
+		return
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		log.Printf("failed to open usage ledger: %v\n", err)
+		return
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(record); err != nil {
+		log.Printf("failed to write usage ledger: %v\n", err)
+	}
+}
+
+// summaries returns the totals for the given date and user, an empty filter matches everything
+func (l *usageLedger) summaries(date, user string) []usageSummary {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var result []usageSummary
+	for key, summary := range l.totals {
+		if date != "" && key.date != date {
+			continue
+		}
+		if user != "" && key.user != user {
+			continue
+		}
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date > result[j].Date
+		}
+		return result[i].User < result[j].User
+	})
+	return result
+}
+
+// usageHandler lists the token usage per user and day, filtered by the date and user query parameters.
+// Only managers can see the usage of other users.
+func (app *App) usageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date != "" {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "invalid_date", "date must have the format YYYY-MM-DD")
+			return
+		}
+	}
+
+	user := r.URL.Query().Get("user")
+	if identity, _ := identityFromContext(r.Context()); identity.Role != roleManager {
+		user = identity.User
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"object": "list",
+		"data":   app.usage.summaries(date, user),
+	})
+}